@@ -7,11 +7,11 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-func runAnalysis() {
+func runAnalysis(cfg BrowserConfig, site SiteConfig) {
 	log.Println("=== ITANDI BB HTML Structure Analysis ===")
 	
-	// Create scraper instance with visible browser for analysis
-	scraper, err := NewITANDIScraper(false)
+	// Create scraper instance (visible unless -headless) for analysis
+	scraper, err := NewITANDIScraperWithConfig(cfg, site, SessionOptions{})
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
@@ -103,7 +103,9 @@ func runAnalysis() {
 	log.Println("- analysis_login_page.png: Login page screenshot")
 	log.Println("- analysis_post_login.png: Post-login screenshot")
 	
-	// Keep browser open for manual inspection
-	log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
-	time.Sleep(30 * time.Second)
+	// Keep browser open for manual inspection if not headless
+	if !cfg.Headless {
+		log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
+		time.Sleep(30 * time.Second)
+	}
 }
\ No newline at end of file
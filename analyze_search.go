@@ -7,11 +7,11 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-func analyzeSearchFlow() {
+func analyzeSearchFlow(cfg BrowserConfig, site SiteConfig, propertyName string) {
 	log.Println("=== Analyzing ITANDI BB Search Flow ===")
 	
-	// Create scraper instance with visible browser
-	scraper, err := NewITANDIScraper(false)
+	// Create scraper instance (visible unless -headless)
+	scraper, err := NewITANDIScraperWithConfig(cfg, site, SessionOptions{})
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
@@ -88,7 +88,7 @@ func analyzeSearchFlow() {
 	log.Println("\n=== Step 3: Clicking List Search ===")
 	
 	// Try the improved search function
-	err = scraper.SearchProperty("テスト物件")
+	err = scraper.SearchProperty(propertyName)
 	if err != nil {
 		log.Printf("Search failed: %v\n", err)
 		
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Batch row statuses
+const (
+	BatchStatusFound     = "found"
+	BatchStatusNoResults = "no_results"
+	BatchStatusError     = "error"
+
+	// BatchStatusRoomNotListed means the building was found but not the row's room
+	BatchStatusRoomNotListed = "room_not_listed"
+
+	// BatchStatusAmbiguousMatch means the results could not be narrowed to the row's building
+	BatchStatusAmbiguousMatch = "ambiguous_match"
+)
+
+// BatchItem は一括確認の入力1行
+type BatchItem struct {
+	Line         int    `json:"line"`
+	PropertyName string `json:"property_name"`
+	RoomNumber   string `json:"room_number,omitempty"`
+
+	// Address and ManagementCompany are optional hints for picking the
+	// building among similarly named results
+	Address           string `json:"address,omitempty"`
+	ManagementCompany string `json:"management_company,omitempty"`
+}
+
+// BatchResult は一括確認の1行分の結果
+type BatchResult struct {
+	BatchItem
+	Status      string        `json:"status"`
+	Error       string        `json:"error,omitempty"`
+	ErrorCode   string        `json:"error_code,omitempty"` // see ErrorCode
+	Result      *SearchResult `json:"result,omitempty"`
+	Diff        *ListingDiff  `json:"diff,omitempty"`       // changes since the previous run, when history is recorded
+	Screenshot  string        `json:"screenshot,omitempty"` // search results screenshot of this run
+	ConfirmedAt time.Time     `json:"confirmed_at"`
+}
+
+// BatchReport is the consolidated output of a batch run
+type BatchReport struct {
+	Input      string        `json:"input"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Total      int           `json:"total"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Results    []BatchResult `json:"results"`
+}
+
+// propertyColumnNames, roomColumnNames, addressColumnNames and
+// companyColumnNames are the accepted header names
+var (
+	propertyColumnNames = []string{"property", "property_name", "name", "物件名", "建物名"}
+	roomColumnNames     = []string{"room", "room_number", "部屋番号", "号室"}
+	addressColumnNames  = []string{"address", "住所", "所在地"}
+	companyColumnNames  = []string{"management_company", "company", "管理会社"}
+)
+
+// readBatchInput reads property names (and optional room numbers) from a CSV
+// file or the first sheet of an Excel workbook. A header row is detected by
+// its column names; without one the first column is the property name and
+// the second the room number. Address and management company columns are
+// only read from a file with a header.
+func readBatchInput(path string) ([]BatchItem, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx", ".xlsm":
+		return readBatchXLSX(path)
+	case ".xls":
+		return nil, fmt.Errorf("old .xls workbooks are not supported - save %s as .xlsx or CSV (UTF-8)", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer f.Close()
+
+	return parseBatchCSV(f)
+}
+
+// parseBatchCSV parses batch input rows from CSV content
+func parseBatchCSV(r io.Reader) ([]BatchItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return parseBatchRecords(records)
+}
+
+// readBatchXLSX reads batch input rows from the first sheet of a workbook
+func readBatchXLSX(path string) ([]BatchItem, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("no sheets in %s", path)
+	}
+	records, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %s: %w", sheets[0], err)
+	}
+	return parseBatchRecords(records)
+}
+
+// parseBatchRecords turns CSV or sheet rows into batch items. Line is the
+// 1-based row number in the file.
+func parseBatchRecords(records [][]string) ([]BatchItem, error) {
+	propertyCol, roomCol, addressCol, companyCol := 0, 1, -1, -1
+	start := 0
+	if len(records) > 0 {
+		header := records[0]
+		if len(header) > 0 {
+			// Strip a UTF-8 BOM written by Excel
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		if col := findColumn(header, propertyColumnNames); col >= 0 {
+			propertyCol = col
+			roomCol = findColumn(header, roomColumnNames)
+			addressCol = findColumn(header, addressColumnNames)
+			companyCol = findColumn(header, companyColumnNames)
+			start = 1
+		}
+	}
+
+	var items []BatchItem
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		if propertyCol >= len(record) {
+			continue
+		}
+		name := strings.TrimSpace(record[propertyCol])
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		item := BatchItem{Line: i + 1, PropertyName: name}
+		item.RoomNumber = csvField(record, roomCol)
+		item.Address = csvField(record, addressCol)
+		item.ManagementCompany = csvField(record, companyCol)
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no property names found in input")
+	}
+	return items, nil
+}
+
+// csvField returns the trimmed value of column col, or "" when the record lacks it
+func csvField(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[col])
+}
+
+// findColumn returns the index of the first header matching one of names, or -1
+func findColumn(header []string, names []string) int {
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for _, name := range names {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// confirmBatchItem runs search and extraction for one row
+func confirmBatchItem(scraper *ITANDIScraper, item BatchItem) BatchResult {
+	res := BatchResult{BatchItem: item}
+
+	criteria := SearchCriteria{
+		PropertyName:      item.PropertyName,
+		Address:           item.Address,
+		ManagementCompany: item.ManagementCompany,
+	}
+	err := scraper.Search(criteria)
+	// Keep the page as this run saw it, including a failed search
+	res.Screenshot = scraper.captureRunScreenshot()
+	if err != nil {
+		res.setError(err)
+		res.ConfirmedAt = time.Now()
+		return res
+	}
+
+	result, err := scraper.GetRoomDetails(item.RoomNumber)
+	res.ConfirmedAt = time.Now()
+	if err != nil {
+		res.setError(err)
+		return res
+	}
+	res.setResult(result)
+	return res
+}
+
+// setResult stores the extracted result and marks the row found, no_results,
+// room_not_listed or ambiguous_match
+func (r *BatchResult) setResult(result *SearchResult) {
+	r.Result = result
+	switch err := result.Err(); {
+	case errors.Is(err, ErrRoomNotListed):
+		r.Status = BatchStatusRoomNotListed
+	case errors.Is(err, ErrAmbiguousMatch):
+		r.Status = BatchStatusAmbiguousMatch
+	case errors.Is(err, ErrNoResults):
+		r.Status = BatchStatusNoResults
+	default:
+		r.Status = BatchStatusFound
+	}
+}
+
+// setError marks the row as failed with err's message and failure class
+func (r *BatchResult) setError(err error) {
+	r.Status = BatchStatusError
+	r.Error = err.Error()
+	r.ErrorCode = ErrorCode(err)
+}
+
+// filterListingsByRoom keeps the listings whose room number matches room
+func filterListingsByRoom(listings []PropertyListing, room string) []PropertyListing {
+	want := normalizeRoomNumber(room)
+	var matched []PropertyListing
+	for _, l := range listings {
+		if normalizeRoomNumber(l.RoomNumber) == want {
+			matched = append(matched, l)
+		}
+	}
+	return matched
+}
+
+// normalizeRoomNumber reduces "302号室", "３０２" and "302" to the same form
+func normalizeRoomNumber(room string) string {
+	room = normalizeValue(room)
+	room = strings.TrimSuffix(room, "号室")
+	room = strings.TrimSuffix(room, "号")
+	return strings.ToUpper(room)
+}
+
+// runBatch logs in once and confirms every property listed in inputPath,
+// spreading the rows over the pool's workers. Errors are returned rather than
+// fatal so the pool's browsers are always closed.
+func runBatch(inputPath string, browserCfg BrowserConfig, site SiteConfig, session SessionOptions, opts PoolOptions, history *HistoryStore, notifier *Notifier, out OutputOptions) error {
+	log.Println("=== ITANDI BB Batch Confirmation ===")
+
+	items, err := readBatchInput(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read batch input: %w", err)
+	}
+	log.Printf("Loaded %d properties from %s\n", len(items), inputPath)
+
+	opts.Workers = min(opts.Workers, len(items))
+	pool, err := NewBrowserPool(browserCfg, site, session, opts)
+	if err != nil {
+		return fmt.Errorf("failed to start browser pool: %w", err)
+	}
+	defer pool.Close()
+
+	report := BatchReport{
+		Input:     inputPath,
+		StartedAt: time.Now(),
+		Total:     len(items),
+		Results:   make([]BatchResult, len(items)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := pool.Confirm(context.Background(), item)
+			recordHistory(history, &res, SourceBatch)
+			notifier.Notify(context.Background(), res)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if res.Status == BatchStatusError {
+				report.Failed++
+				log.Printf("[%d/%d] Row %d %s %s failed (%s): %s\n", i+1, len(items), item.Line, item.PropertyName, item.RoomNumber, res.ErrorCode, res.Error)
+			} else {
+				report.Succeeded++
+				log.Printf("[%d/%d] Row %d %s %s: %s\n", i+1, len(items), item.Line, item.PropertyName, item.RoomNumber, res.Status)
+				if res.Diff != nil && res.Diff.HasChanges() {
+					log.Print(res.Diff.Summary())
+				}
+			}
+			report.Results[i] = res
+		}()
+	}
+	wg.Wait()
+	report.FinishedAt = time.Now()
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode batch results: %w", err)
+	}
+
+	fileName := fmt.Sprintf("batch_results_%s.json", time.Now().Format("20060102_150405"))
+	if err := os.WriteFile(fileName, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to save batch results: %w", err)
+	}
+
+	// The JSON report is already saved, so a failed -output loses nothing
+	summary := os.Stdout
+	var writeErr error
+	if out.Enabled() {
+		// Keep stdout clean when the results themselves are written there
+		if out.ToStdout() {
+			summary = os.Stderr
+		}
+		writeErr = out.Write(report.Results)
+	}
+
+	fmt.Fprintf(summary, "\nBatch completed: %d succeeded, %d failed (total %d) in %s\n",
+		report.Succeeded, report.Failed, report.Total, report.FinishedAt.Sub(report.StartedAt).Round(time.Second))
+	fmt.Fprintf(summary, "Results saved to: %s\n", fileName)
+	if writeErr != nil {
+		return fmt.Errorf("failed to write results (they are saved in %s): %w", fileName, writeErr)
+	}
+	if out.Enabled() && !out.ToStdout() {
+		fmt.Fprintf(summary, "Results written to: %s\n", out.Path)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseBatchCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []BatchItem
+	}{
+		{
+			name:  "header with BOM",
+			input: "\ufeff物件名,部屋番号\nクレール住吉,302\nサンプル物件,\n",
+			want: []BatchItem{
+				{Line: 2, PropertyName: "クレール住吉", RoomNumber: "302"},
+				{Line: 3, PropertyName: "サンプル物件"},
+			},
+		},
+		{
+			name:  "headerless",
+			input: "クレール住吉,302\nサンプル物件\n",
+			want: []BatchItem{
+				{Line: 1, PropertyName: "クレール住吉", RoomNumber: "302"},
+				{Line: 2, PropertyName: "サンプル物件"},
+			},
+		},
+		{
+			name:  "header in another order with hints",
+			input: "room,管理会社,property,住所\n302,株式会社Room,クレール住吉, 大阪市住吉区長居1-2-3 \n",
+			want: []BatchItem{
+				{Line: 2, PropertyName: "クレール住吉", RoomNumber: "302", Address: "大阪市住吉区長居1-2-3", ManagementCompany: "株式会社Room"},
+			},
+		},
+		{
+			name:  "comments, blank names and short rows",
+			input: "部屋番号,物件名\n# 確認不要,\n302\n, \n101,クレール住吉\n",
+			want: []BatchItem{
+				{Line: 5, PropertyName: "クレール住吉", RoomNumber: "101"},
+			},
+		},
+		{
+			name:  "commented property",
+			input: "#クレール住吉,302\nサンプル物件,101\n",
+			want: []BatchItem{
+				{Line: 2, PropertyName: "サンプル物件", RoomNumber: "101"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBatchCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("parseBatchCSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBatchCSVEmpty(t *testing.T) {
+	for _, input := range []string{"", "物件名,部屋番号\n", "# only a comment\n"} {
+		if _, err := parseBatchCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("parseBatchCSV(%q) accepted input without properties", input)
+		}
+	}
+}
+
+func TestReadBatchInputXLSX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "properties.xlsx")
+	f := excelize.NewFile()
+	rows := [][]any{
+		{"物件名", "部屋番号"},
+		{"クレール住吉", 302},
+		{"サンプル物件"},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := readBatchInput(path)
+	if err != nil {
+		t.Fatalf("readBatchInput: %v", err)
+	}
+	want := []BatchItem{
+		{Line: 2, PropertyName: "クレール住吉", RoomNumber: "302"},
+		{Line: 3, PropertyName: "サンプル物件"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %+v, want %+v", got, want)
+	}
+
+	if _, err := readBatchInput(filepath.Join(t.TempDir(), "old.xls")); err == nil {
+		t.Error(".xls input accepted")
+	}
+}
+
+func TestFindColumn(t *testing.T) {
+	header := []string{" Room ", "PROPERTY_NAME", "管理会社"}
+	if got := findColumn(header, propertyColumnNames); got != 1 {
+		t.Errorf("property column = %d, want 1", got)
+	}
+	if got := findColumn(header, roomColumnNames); got != 0 {
+		t.Errorf("room column = %d, want 0", got)
+	}
+	if got := findColumn(header, addressColumnNames); got != -1 {
+		t.Errorf("address column = %d, want -1", got)
+	}
+}
+
+func TestCSVField(t *testing.T) {
+	record := []string{" クレール住吉 ", "302"}
+	for col, want := range map[int]string{0: "クレール住吉", 1: "302", 2: "", -1: ""} {
+		if got := csvField(record, col); got != want {
+			t.Errorf("csvField(%d) = %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestNormalizeRoomNumber(t *testing.T) {
+	for _, room := range []string{"302", "302号室", "３０２", "302号", " 302 "} {
+		if got := normalizeRoomNumber(room); got != "302" {
+			t.Errorf("normalizeRoomNumber(%q) = %q, want 302", room, got)
+		}
+	}
+	if got := normalizeRoomNumber("b-101"); got != "B-101" {
+		t.Errorf("normalizeRoomNumber(b-101) = %q, want B-101", got)
+	}
+}
+
+func TestFilterListingsByRoom(t *testing.T) {
+	listings := []PropertyListing{
+		{Name: "クレール住吉", RoomNumber: "302"},
+		{Name: "クレール住吉", RoomNumber: "３０２号室"},
+		{Name: "クレール住吉", RoomNumber: "101"},
+		{Name: "クレール住吉"},
+	}
+	got := filterListingsByRoom(listings, "302号室")
+	if len(got) != 2 || got[0].RoomNumber != "302" || got[1].RoomNumber != "３０２号室" {
+		t.Errorf("filterListingsByRoom(302号室) = %+v", got)
+	}
+	if got := filterListingsByRoom(listings, "201"); len(got) != 0 {
+		t.Errorf("filterListingsByRoom(201) = %+v, want none", got)
+	}
+}
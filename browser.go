@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// chromiumPathEnvs are checked in order when no executable path is configured
+var chromiumPathEnvs = []string{"CHROMIUM_PATH", "CHROME_PATH"}
+
+// knownChromiumPaths are probed when neither the config nor the environment names an executable
+var knownChromiumPaths = []string{
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/usr/bin/chromium",
+	"/usr/bin/chromium-browser",
+	"/usr/bin/google-chrome",
+	"/usr/bin/google-chrome-stable",
+	"/snap/bin/chromium",
+	"/opt/google/chrome/chrome",
+}
+
+// BrowserConfig はChromiumの起動設定
+type BrowserConfig struct {
+	ExecPath     string   `json:"exec_path"`
+	Headless     bool     `json:"headless"`
+	WindowWidth  int      `json:"window_width"`
+	WindowHeight int      `json:"window_height"`
+	UserAgent    string   `json:"user_agent"`
+	ProxyServer  string   `json:"proxy_server"`
+	Locale       string   `json:"locale"`
+	Timezone     string   `json:"timezone"`
+	UserDataDir  string   `json:"user_data_dir"`
+	ExtraFlags   []string `json:"extra_flags"` // "name" or "name=value"
+}
+
+// DefaultBrowserConfig returns the settings used when nothing is configured
+func DefaultBrowserConfig() BrowserConfig {
+	return BrowserConfig{
+		WindowWidth:  1280,
+		WindowHeight: 900,
+		Locale:       "ja-JP",
+		Timezone:     "Asia/Tokyo",
+	}
+}
+
+// LoadBrowserConfig reads a JSON browser config on top of the defaults
+func LoadBrowserConfig(path string) (BrowserConfig, error) {
+	cfg := DefaultBrowserConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read browser config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse browser config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ResolveExecPath returns the Chromium executable to launch: the configured
+// path, then CHROMIUM_PATH/CHROME_PATH, then the first known install location.
+// An empty result lets chromedp use its own lookup.
+func (c BrowserConfig) ResolveExecPath() string {
+	if c.ExecPath != "" {
+		return c.ExecPath
+	}
+	for _, env := range chromiumPathEnvs {
+		if path := os.Getenv(env); path != "" {
+			return path
+		}
+	}
+	for _, path := range knownChromiumPaths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// allocatorOptions converts the config into chromedp allocator options
+func (c BrowserConfig) allocatorOptions() []chromedp.ExecAllocatorOption {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", c.Headless),
+	)
+
+	if path := c.ResolveExecPath(); path != "" {
+		opts = append(opts, chromedp.ExecPath(path))
+	}
+	if c.WindowWidth > 0 && c.WindowHeight > 0 {
+		opts = append(opts, chromedp.WindowSize(c.WindowWidth, c.WindowHeight))
+	}
+	if c.UserAgent != "" {
+		opts = append(opts, chromedp.UserAgent(c.UserAgent))
+	}
+	if c.ProxyServer != "" {
+		opts = append(opts, chromedp.ProxyServer(c.ProxyServer))
+	}
+	if c.Locale != "" {
+		opts = append(opts,
+			chromedp.Flag("lang", c.Locale),
+			chromedp.Flag("accept-lang", c.Locale),
+		)
+	}
+	if c.Timezone != "" {
+		opts = append(opts, chromedp.Env("TZ="+c.Timezone))
+	}
+	if c.UserDataDir != "" {
+		opts = append(opts, chromedp.UserDataDir(c.UserDataDir))
+	}
+	for _, flag := range c.ExtraFlags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if hasValue {
+			opts = append(opts, chromedp.Flag(name, value))
+		} else {
+			opts = append(opts, chromedp.Flag(name, true))
+		}
+	}
+
+	return opts
+}
+
+// newBrowserContext starts Chromium from cfg and returns a tab context with a
+// cancel function that also shuts down the browser
+func newBrowserContext(cfg BrowserConfig) (context.Context, context.CancelFunc, error) {
+	if cfg.UserDataDir != "" {
+		if err := os.MkdirAll(cfg.UserDataDir, 0700); err != nil {
+			return nil, nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+	}
+
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), cfg.allocatorOptions()...)
+	ctx, cancel2 := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+
+	// Create a combined cancel function
+	combinedCancel := func() {
+		cancel2()
+		cancel()
+	}
+
+	return ctx, combinedCancel, nil
+}
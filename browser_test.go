@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// fakeExecutable writes an executable shell script and returns its path
+func fakeExecutable(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script executables are not supported on Windows")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// isolateExecLookup clears every source ResolveExecPath consults
+func isolateExecLookup(t *testing.T) {
+	t.Helper()
+	for _, env := range chromiumPathEnvs {
+		t.Setenv(env, "")
+	}
+	t.Setenv("PATH", t.TempDir())
+	saved := knownChromiumPaths
+	knownChromiumPaths = nil
+	t.Cleanup(func() { knownChromiumPaths = saved })
+}
+
+func TestResolveExecPathPrecedence(t *testing.T) {
+	isolateExecLookup(t)
+	dir := t.TempDir()
+
+	if got := (BrowserConfig{}).ResolveExecPath(); got != "" {
+		t.Errorf("nothing available: ResolveExecPath() = %q, want empty", got)
+	}
+
+	pathDir := t.TempDir()
+	onPath := fakeExecutable(t, pathDir, "chromium", "exit 0\n")
+	t.Setenv("PATH", pathDir)
+	if got := (BrowserConfig{}).ResolveExecPath(); got != onPath {
+		t.Errorf("PATH lookup: ResolveExecPath() = %q, want %q", got, onPath)
+	}
+
+	known := fakeExecutable(t, dir, "known-chrome", "exit 0\n")
+	knownChromiumPaths = []string{filepath.Join(dir, "missing"), dir, known}
+	if got := (BrowserConfig{}).ResolveExecPath(); got != known {
+		t.Errorf("known paths: ResolveExecPath() = %q, want %q (directories and missing files skipped)", got, known)
+	}
+
+	t.Setenv("CHROME_PATH", "/env/chrome")
+	if got := (BrowserConfig{}).ResolveExecPath(); got != "/env/chrome" {
+		t.Errorf("CHROME_PATH: ResolveExecPath() = %q", got)
+	}
+	t.Setenv("CHROMIUM_PATH", "/env/chromium")
+	if got := (BrowserConfig{}).ResolveExecPath(); got != "/env/chromium" {
+		t.Errorf("CHROMIUM_PATH before CHROME_PATH: ResolveExecPath() = %q", got)
+	}
+
+	if got := (BrowserConfig{ExecPath: "/config/chromium"}).ResolveExecPath(); got != "/config/chromium" {
+		t.Errorf("config: ResolveExecPath() = %q", got)
+	}
+}
+
+func TestLoadBrowserConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "browser.json")
+	if err := os.WriteFile(path, []byte(`{"headless": true, "user_agent": "crm-test"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadBrowserConfig(path)
+	if err != nil {
+		t.Fatalf("LoadBrowserConfig: %v", err)
+	}
+	// Unset fields keep their defaults
+	if !cfg.Headless || cfg.UserAgent != "crm-test" || cfg.WindowWidth != 1280 || cfg.Locale != "ja-JP" {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"headless": "yes"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBrowserConfig(path); err == nil {
+		t.Error("invalid browser config accepted")
+	}
+}
+
+// TestAllocatorOptions launches a fake browser that records its arguments
+// and environment, then exits before chromedp can connect
+func TestAllocatorOptions(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	envFile := filepath.Join(dir, "env")
+	exe := fakeExecutable(t, dir, "fake-chromium",
+		"printf '%s\\n' \"$@\" > '"+argsFile+"'\nenv > '"+envFile+"'\n")
+
+	cfg := DefaultBrowserConfig()
+	cfg.ExecPath = exe
+	cfg.Headless = true
+	cfg.UserAgent = "crm-test"
+	cfg.ProxyServer = "http://proxy.example:8080"
+	cfg.ExtraFlags = []string{"--disable-gpu", "force-device-scale-factor=2"}
+
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), cfg.allocatorOptions()...)
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := chromedp.Run(ctx); err == nil {
+		t.Fatal("fake browser accepted a connection")
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("fake browser was not launched: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, want := range []string{
+		"--headless",
+		"--window-size=1280,900",
+		"--user-agent=crm-test",
+		"--proxy-server=http://proxy.example:8080",
+		"--lang=ja-JP",
+		"--accept-lang=ja-JP",
+		"--disable-gpu",
+		"--force-device-scale-factor=2",
+	} {
+		if !slices.Contains(args, want) {
+			t.Errorf("arguments %v missing %s", args, want)
+		}
+	}
+
+	env, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(strings.Split(string(env), "\n"), "TZ=Asia/Tokyo") {
+		t.Error("TZ=Asia/Tokyo not passed to the browser")
+	}
+}
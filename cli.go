@@ -0,0 +1,694 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+// commands returns the subcommands in the order shown by help
+func commands() []command {
+	return []command{
+		{"confirm", "Confirm one property and save its details", runConfirm},
+		{"search", "Search the rent list by area, station, rent, layout and other criteria", runSearchCommand},
+		{"batch", "Confirm every property of a CSV or Excel file in one session", runBatchCommand},
+		{"serve", "Serve the confirmation REST API", runServeCommand},
+		{"schedule", "Confirm a watch list on cron schedules until interrupted", runScheduleCommand},
+		{"history", "Show recorded confirmations", runHistory},
+		{"diff", "Compare the two latest confirmations of a property", runDiff},
+		{"session", "Log in and save the session, or check a saved one", runSessionCommand},
+		{"selectors", "Check a selector config (selectors check)", runSelectorsCommand},
+		{"analyze", "Inspect ITANDI BB pages (login, search, results, ...)", runAnalyzeCommand},
+	}
+}
+
+// analyzeTargets are the pages "analyze" can inspect
+var analyzeTargets = []struct {
+	name    string
+	summary string
+}{
+	{"login", "Structure of the login page and the page after login"},
+	{"search", "Links and modules of the top page and the search flow"},
+	{"results", "Tables and cards of a search result page"},
+	{"find-login", "Probe candidate URLs for an email/password login form"},
+	{"email-login", "Log in through the email/password form"},
+	{"phone", "Walk through the phone verification login"},
+	{"modal", "Close the advertisement modals after login"},
+}
+
+// runCLI dispatches args (without the program name) to a subcommand
+func runCLI(args []string) {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := args[0]
+	if strings.HasPrefix(name, "-") && name != "-h" && name != "-help" && name != "--help" {
+		// Flags without a subcommand used to confirm a property
+		log.Println(`No subcommand given - running "confirm"`)
+		runConfirm(args)
+		return
+	}
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			runCLI([]string{args[1], "-h"})
+			return
+		}
+		printUsage(os.Stdout)
+		return
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			cmd.run(args[1:])
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(2)
+}
+
+// printUsage lists the subcommands
+func printUsage(w *os.File) {
+	fmt.Fprintln(w, "Usage: crm <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun \"crm <command> -h\" for the flags of a command.")
+}
+
+// newFlagSet creates the flag set of a subcommand with a usage line
+func newFlagSet(name, arguments, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: crm %s %s\n\n%s\n\nFlags:\n", name, arguments, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// cliOptions holds the flags shared by several subcommands. Each subcommand
+// registers only the groups it uses.
+type cliOptions struct {
+	headless      bool
+	browserConfig string
+	chromePath    string
+	proxy         string
+	userAgent     string
+
+	siteConfig  string
+	accountsURL string
+	bbURL       string
+
+	profileDir  string
+	sessionFile string
+
+	selectors   string
+	retryConfig string
+	retries     int
+	details     bool
+
+	workers      int
+	recycleAfter int
+	rateLimit    time.Duration
+
+	db           string
+	notifyConfig string
+
+	format string
+	output string
+}
+
+// browserFlags registers the Chromium and site endpoint flags
+func (o *cliOptions) browserFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.headless, "headless", false, "Run in headless mode")
+	fs.StringVar(&o.browserConfig, "browser-config", "", "JSON file with Chromium settings (exec_path, window size, user agent, proxy, locale, timezone, extra_flags)")
+	fs.StringVar(&o.chromePath, "chrome-path", "", "Chromium/Chrome executable (default: CHROMIUM_PATH, CHROME_PATH or auto-detect)")
+	fs.StringVar(&o.proxy, "proxy", "", "Proxy server for Chromium (e.g. http://proxy:8080)")
+	fs.StringVar(&o.userAgent, "user-agent", "", "User agent override for Chromium")
+	fs.StringVar(&o.siteConfig, "site-config", "", "JSON file with site endpoints (accounts_url, bb_url, paths, login_candidates)")
+	fs.StringVar(&o.accountsURL, "accounts-url", "", "ITANDI accounts base URL (default: https://itandi-accounts.com)")
+	fs.StringVar(&o.bbURL, "bb-url", "", "ITANDI BB base URL (default: https://itandibb.com)")
+}
+
+// sessionFlags registers the login session reuse flags
+func (o *cliOptions) sessionFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.profileDir, "profile-dir", "", "Persistent Chromium profile directory to reuse the login session")
+	fs.StringVar(&o.sessionFile, "session-file", "", "Encrypted cookie/localStorage file to reuse the login session (requires ITANDI_SESSION_KEY)")
+}
+
+// scraperFlags registers the selector, retry and detail page flags
+func (o *cliOptions) scraperFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.selectors, "selectors", "", "Selector config file (JSON); reloaded automatically when it changes")
+	fs.StringVar(&o.retryConfig, "retry-config", "", "JSON file with the retry policy (max_attempts, backoff, jitter, retry_on)")
+	fs.IntVar(&o.retries, "retries", 0, "Maximum attempts per step, overriding the retry policy (1 disables retries)")
+	fs.BoolVar(&o.details, "details", false, "Open each result's 詳細 page in a new tab and add facilities, contract and fee details")
+}
+
+// poolFlags registers the browser pool flags
+func (o *cliOptions) poolFlags(fs *flag.FlagSet) {
+	fs.IntVar(&o.workers, "workers", 1, "Number of browser tabs confirming properties in parallel")
+	fs.IntVar(&o.recycleAfter, "recycle-after", 50, "Reopen a worker's tab after this many confirmations (0 = never)")
+	fs.DurationVar(&o.rateLimit, "rate-limit", time.Second, "Minimum interval between confirmations starting, across all workers")
+}
+
+// resultFlags registers the history and notification flags
+func (o *cliOptions) resultFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.db, "db", defaultHistoryDB, "SQLite database recording every confirmation (empty to disable)")
+	fs.StringVar(&o.notifyConfig, "notify-config", "", "JSON file with notification sinks (webhook, slack, email) and their filters")
+}
+
+// outputFlags registers the result file format flags
+func (o *cliOptions) outputFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "", "Write results as json, jsonl, csv or xlsx (default: from the -output extension)")
+	fs.StringVar(&o.output, "output", "", "File to write the results to; \"-\" or empty writes to stdout (xlsx needs a file)")
+}
+
+// outputOptions returns the validated result output settings
+func (o *cliOptions) outputOptions() OutputOptions {
+	out := OutputOptions{Format: o.format, Path: o.output}
+	if err := out.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return out
+}
+
+// browser returns the Chromium settings from -browser-config and the override flags
+func (o *cliOptions) browser() BrowserConfig {
+	cfg := DefaultBrowserConfig()
+	if o.browserConfig != "" {
+		loaded, err := LoadBrowserConfig(o.browserConfig)
+		if err != nil {
+			log.Fatal("Failed to load browser config:", err)
+		}
+		cfg = loaded
+	}
+	cfg.Headless = cfg.Headless || o.headless
+	if o.chromePath != "" {
+		cfg.ExecPath = o.chromePath
+	}
+	if o.proxy != "" {
+		cfg.ProxyServer = o.proxy
+	}
+	if o.userAgent != "" {
+		cfg.UserAgent = o.userAgent
+	}
+	return cfg
+}
+
+// site returns the endpoints from -site-config and the URL flags
+func (o *cliOptions) site() SiteConfig {
+	site := DefaultSiteConfig()
+	if o.siteConfig != "" {
+		cfg, err := LoadSiteConfig(o.siteConfig)
+		if err != nil {
+			log.Fatal("Failed to load site config:", err)
+		}
+		site = cfg
+	}
+	if o.accountsURL != "" {
+		site.AccountsURL = o.accountsURL
+	}
+	if o.bbURL != "" {
+		site.BBURL = o.bbURL
+	}
+	if err := site.Validate(); err != nil {
+		log.Fatal("Invalid site config:", err)
+	}
+	return site
+}
+
+// session returns the session reuse options
+func (o *cliOptions) session() SessionOptions {
+	return SessionOptions{ProfileDir: o.profileDir, File: o.sessionFile}
+}
+
+// selectorStore loads -selectors and watches it, or returns the built-in selectors
+func (o *cliOptions) selectorStore() *SelectorStore {
+	if o.selectors == "" {
+		return NewSelectorStore()
+	}
+	store, err := LoadSelectorStore(o.selectors)
+	if err != nil {
+		log.Fatal("Failed to load selectors:", err)
+	}
+	store.Watch(context.Background(), 5*time.Second)
+	return store
+}
+
+// retryPolicy returns the policy from -retry-config with -retries applied
+func (o *cliOptions) retryPolicy() RetryPolicy {
+	retry := DefaultRetryPolicy()
+	if o.retryConfig != "" {
+		policy, err := LoadRetryPolicy(o.retryConfig)
+		if err != nil {
+			log.Fatal("Failed to load retry config:", err)
+		}
+		retry = policy
+	}
+	if o.retries > 0 {
+		retry.MaxAttempts = o.retries
+	}
+	return retry
+}
+
+// poolOptions returns the pool settings, including selectors and retries
+func (o *cliOptions) poolOptions() PoolOptions {
+	opts := DefaultPoolOptions()
+	opts.Workers = o.workers
+	opts.RecycleAfter = o.recycleAfter
+	opts.MinInterval = o.rateLimit
+	opts.Selectors = o.selectorStore()
+	opts.Retry = o.retryPolicy()
+	opts.DetailPages = o.details
+	return opts
+}
+
+// historyStore opens -db, or returns nil when history is disabled. The
+// caller closes it.
+func (o *cliOptions) historyStore() *HistoryStore {
+	if o.db == "" {
+		return nil
+	}
+	store, err := OpenHistoryStore(o.db)
+	if err != nil {
+		log.Fatal("Failed to open history database:", err)
+	}
+	return store
+}
+
+// notifier loads -notify-config, or returns nil when notifications are off
+func (o *cliOptions) notifier() *Notifier {
+	if o.notifyConfig == "" {
+		return nil
+	}
+	cfg, err := LoadNotifyConfig(o.notifyConfig)
+	if err != nil {
+		log.Fatal("Failed to load notify config:", err)
+	}
+	notifier, err := NewNotifier(cfg)
+	if err != nil {
+		log.Fatal("Invalid notify config:", err)
+	}
+	return notifier
+}
+
+// runSearchCommand implements "search"
+func runSearchCommand(args []string) {
+	fs := newFlagSet("search", "[flags]", "Search the ITANDI BB rent list by criteria and list every matching room.\nFlags override the same fields of -criteria.")
+	var opts cliOptions
+	criteriaFile := fs.String("criteria", "", "JSON file with search criteria (property_name, prefecture, station, rent_max, layouts, ...)")
+	var c SearchCriteria
+	fs.StringVar(&c.PropertyName, "property", "", "Property name (optional)")
+	fs.StringVar(&c.Prefecture, "prefecture", "", "Prefecture, e.g. 大阪府")
+	fs.StringVar(&c.Municipality, "municipality", "", "City or ward, e.g. 大阪市住吉区")
+	fs.StringVar(&c.Station, "station", "", "Station name, e.g. 長居")
+	fs.IntVar(&c.WalkMinutes, "walk", 0, "Maximum walk from the station in minutes")
+	rentMin := fs.String("rent-min", "", "Minimum rent, e.g. 50000 or 5万")
+	rentMax := fs.String("rent-max", "", "Maximum rent, e.g. 80000 or 8万")
+	layouts := fs.String("layout", "", "Comma-separated layouts, e.g. 1K,1LDK (ワンルーム is 1R)")
+	fs.Float64Var(&c.AreaMin, "area-min", 0, "Minimum area in ㎡")
+	fs.Float64Var(&c.AreaMax, "area-max", 0, "Maximum area in ㎡")
+	fs.IntVar(&c.BuildingAgeMax, "max-age", 0, "Maximum building age in years")
+	fs.BoolVar(&c.RecruitingOnly, "recruiting", false, "Only rooms currently 募集中")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.outputFlags(fs)
+	fs.Parse(args)
+
+	criteria := SearchCriteria{}
+	if *criteriaFile != "" {
+		loaded, err := LoadSearchCriteria(*criteriaFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		criteria = loaded
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "property":
+			criteria.PropertyName = c.PropertyName
+		case "prefecture":
+			criteria.Prefecture = c.Prefecture
+		case "municipality":
+			criteria.Municipality = c.Municipality
+		case "station":
+			criteria.Station = c.Station
+		case "walk":
+			criteria.WalkMinutes = c.WalkMinutes
+		case "rent-min":
+			criteria.RentMin = parseRentFlag(f.Name, *rentMin)
+		case "rent-max":
+			criteria.RentMax = parseRentFlag(f.Name, *rentMax)
+		case "layout":
+			criteria.Layouts = nil
+			for _, layout := range strings.Split(*layouts, ",") {
+				if layout = strings.TrimSpace(layout); layout != "" {
+					criteria.Layouts = append(criteria.Layouts, layout)
+				}
+			}
+		case "area-min":
+			criteria.AreaMin = c.AreaMin
+		case "area-max":
+			criteria.AreaMax = c.AreaMax
+		case "max-age":
+			criteria.BuildingAgeMax = c.BuildingAgeMax
+		case "recruiting":
+			criteria.RecruitingOnly = c.RecruitingOnly
+		}
+	})
+	if err := criteria.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	out := opts.outputOptions()
+
+	scraper, err := NewITANDIScraperWithConfig(opts.browser(), opts.site(), opts.session())
+	if err != nil {
+		log.Fatal("Failed to create scraper:", err)
+	}
+	defer scraper.Close()
+	scraper.UseSelectors(opts.selectorStore())
+	scraper.UseRetryPolicy(opts.retryPolicy())
+	scraper.UseDetailPages(opts.details)
+
+	if err := scraper.EnsureLoggedIn(); err != nil {
+		log.Fatal("Failed to login:", err)
+	}
+	if err := scraper.Search(criteria); err != nil {
+		log.Fatal("Failed to search:", err)
+	}
+	result, err := scraper.GetRoomDetails("")
+
+	res := BatchResult{BatchItem: BatchItem{PropertyName: criteria.String()}, ConfirmedAt: time.Now()}
+	if err != nil {
+		res.setError(err)
+		log.Printf("Warning: Failed to get search results: %v\n", err)
+	} else {
+		res.setResult(result)
+	}
+
+	if out.Enabled() {
+		if err := out.Write([]BatchResult{res}); err != nil {
+			log.Fatal("Failed to write results:", err)
+		}
+	} else if err == nil {
+		printPropertyDetails(result)
+	}
+	if err != nil {
+		scraper.Close()
+		os.Exit(1)
+	}
+}
+
+// parseRentFlag parses a rent flag written as 80000, 8万 or 8万円
+func parseRentFlag(name, value string) int {
+	if value == "" {
+		return 0
+	}
+	if yen, err := strconv.Atoi(value); err == nil {
+		return yen
+	}
+	yen, err := ParseYen(value)
+	if err != nil {
+		log.Fatalf("invalid -%s %q: %v", name, value, err)
+	}
+	return yen
+}
+
+// runBatchCommand implements "batch"
+func runBatchCommand(args []string) {
+	fs := newFlagSet("batch", "[flags] <properties.csv|properties.xlsx>", "Confirm every property (name, optional room number) of a CSV file or the first sheet of an Excel workbook in one session.")
+	var opts cliOptions
+	input := fs.String("input", "", "CSV or Excel file of properties (same as the argument)")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.poolFlags(fs)
+	opts.resultFlags(fs)
+	opts.outputFlags(fs)
+	fs.Parse(args)
+
+	path := orDefault(fs.Arg(0), *input)
+	if path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	history := opts.historyStore()
+	err := runBatch(path, opts.browser(), opts.site(), opts.session(), opts.poolOptions(), history, opts.notifier(), opts.outputOptions())
+	if history != nil {
+		history.Close()
+	}
+	// Exit only here, after runBatch has closed the browser pool
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runServeCommand implements "serve"
+func runServeCommand(args []string) {
+	fs := newFlagSet("serve", "[flags]", "Serve the confirmation REST API with a pool of logged-in tabs.")
+	var opts cliOptions
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.poolFlags(fs)
+	opts.resultFlags(fs)
+	fs.Parse(args)
+
+	history := opts.historyStore()
+	err := runServer(*addr, opts.browser(), opts.site(), opts.session(), opts.poolOptions(), history, opts.notifier())
+	if history != nil {
+		history.Close()
+	}
+	// Exit non-zero so a supervisor sees a server that never started or died
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runScheduleCommand implements "schedule"
+func runScheduleCommand(args []string) {
+	fs := newFlagSet("schedule", "[flags] <watch.json>", "Confirm the properties of a watch list on their cron schedules until interrupted.")
+	var opts cliOptions
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.poolFlags(fs)
+	opts.resultFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	list, err := LoadWatchList(fs.Arg(0))
+	if err != nil {
+		log.Fatal("Failed to load watch list:", err)
+	}
+
+	history := opts.historyStore()
+	if history != nil {
+		defer history.Close()
+	}
+	runScheduler(list, opts.browser(), opts.site(), opts.session(), opts.poolOptions(), history, opts.notifier())
+}
+
+// runSessionCommand implements "session"
+func runSessionCommand(args []string) {
+	fs := newFlagSet("session", "[flags]", "Log in once and save the session for later runs, or check whether a saved session is still valid.")
+	var opts cliOptions
+	check := fs.Bool("check", false, "Only report whether the saved session is valid; do not log in (exit status 1 when expired)")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	fs.Parse(args)
+
+	session := opts.session()
+	if session.ProfileDir == "" && session.File == "" {
+		log.Fatal("session needs -profile-dir or -session-file")
+	}
+
+	scraper, err := NewITANDIScraperWithConfig(opts.browser(), opts.site(), session)
+	if err != nil {
+		log.Fatal("Failed to create scraper:", err)
+	}
+	defer scraper.Close()
+
+	if *check {
+		if session.File != "" {
+			if err := scraper.LoadSession(session.File); err != nil {
+				log.Fatal("Failed to load session:", err)
+			}
+		}
+		valid, err := scraper.IsSessionValid()
+		if err != nil {
+			log.Fatal("Session check failed:", err)
+		}
+		if !valid {
+			fmt.Println("Session expired")
+			scraper.Close()
+			os.Exit(1)
+		}
+		fmt.Println("Session valid")
+		return
+	}
+
+	if err := scraper.EnsureLoggedIn(); err != nil {
+		log.Fatal("Failed to login:", err)
+	}
+	fmt.Println("Logged in - the session will be reused by later runs")
+}
+
+// runSelectorsCommand implements "selectors check"
+func runSelectorsCommand(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "Usage: crm selectors check [flags] [selectors.json]")
+		os.Exit(2)
+	}
+	fs := newFlagSet("selectors check", "[flags] [selectors.json]", "Validate a selector config (default: the built-in selectors) and optionally try it on ITANDI BB.")
+	var opts cliOptions
+	live := fs.Bool("live", false, "Open ITANDI BB and report which selectors match on the login page and after login")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	fs.Parse(args[1:])
+
+	name, cfg := "built-in selectors", DefaultSelectorConfig()
+	if path := fs.Arg(0); path != "" {
+		loaded, err := LoadSelectorConfig(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		name, cfg = path, loaded
+	}
+	fmt.Printf("%s: OK (%d fields)\n", name, len(cfg.Selectors))
+	if !*live {
+		return
+	}
+
+	scraper, err := NewITANDIScraperWithConfig(opts.browser(), opts.site(), opts.session())
+	if err != nil {
+		log.Fatal("Failed to create scraper:", err)
+	}
+	defer scraper.Close()
+	scraper.UseSelectors(&SelectorStore{cfg: cfg})
+
+	if err := scraper.NavigateToLogin(); err != nil {
+		log.Fatal("Failed to navigate:", err)
+	}
+	fmt.Println("\nLogin page:")
+	missing := printSelectorMatches(scraper.ctx, cfg, "login.")
+
+	if err := scraper.EnsureLoggedIn(); err != nil {
+		log.Fatal("Failed to login:", err)
+	}
+	fmt.Println("\nAfter login:")
+	missing += printSelectorMatches(scraper.ctx, cfg, "search.")
+	missing += printSelectorMatches(scraper.ctx, cfg, "modal.")
+
+	if missing > 0 {
+		fmt.Printf("\n%d fields did not match. Fields of later pages (such as the search form) may not be on these pages.\n", missing)
+	}
+}
+
+// printSelectorMatches prints which selector of each field under prefix
+// matches the current page, returning the number of fields with no match
+func printSelectorMatches(ctx context.Context, cfg *SelectorConfig, prefix string) int {
+	missing := 0
+	fields := cfg.Prefixed(prefix)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		matched := -1
+		for i, selector := range fields[name] {
+			if locatorExists(ctx, []string{selector}) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			missing++
+			fmt.Printf("  %-30s no match\n", prefix+name)
+			continue
+		}
+		fmt.Printf("  %-30s [%d] %s\n", prefix+name, matched, fields[name][matched])
+	}
+	return missing
+}
+
+// runAnalyzeCommand implements "analyze <target>"
+func runAnalyzeCommand(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, "Usage: crm analyze <target> [flags]\n\nTargets:")
+		for _, t := range analyzeTargets {
+			fmt.Fprintf(os.Stderr, "  %-12s %s\n", t.name, t.summary)
+		}
+		os.Exit(2)
+	}
+
+	target := args[0]
+	summary := ""
+	for _, t := range analyzeTargets {
+		if t.name == target {
+			summary = t.summary
+		}
+	}
+	if summary == "" {
+		log.Fatalf("unknown analyze target %q", target)
+	}
+
+	fs := newFlagSet("analyze "+target, "[flags]", summary+".")
+	var opts cliOptions
+	opts.browserFlags(fs)
+	propertyName := "テスト物件"
+	if target == "search" || target == "results" || target == "phone" {
+		fs.StringVar(&propertyName, "property", propertyName, "Property name to search for")
+	}
+	companyName := "クレール"
+	if target == "phone" {
+		fs.StringVar(&companyName, "company", companyName, "Company name to select on the phone verification page")
+	}
+	fs.Parse(args[1:])
+
+	cfg, site := opts.browser(), opts.site()
+	switch target {
+	case "login":
+		runAnalysis(cfg, site)
+	case "search":
+		analyzeSearchFlow(cfg, site, propertyName)
+	case "results":
+		analyzeDetailedSearch(cfg, site, propertyName)
+	case "find-login":
+		findLoginPage(cfg, site)
+	case "email-login":
+		runEmailLogin(cfg, site)
+	case "phone":
+		runUpdatedScraper(cfg, site, companyName, propertyName)
+	case "modal":
+		testModalHandling(cfg, site)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCLIOptionsReachConfigs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var opts cliOptions
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.poolFlags(fs)
+
+	err := fs.Parse([]string{
+		"-headless", "-proxy", "http://proxy:8080", "-bb-url", "http://127.0.0.1:9000",
+		"-session-file", "s.enc", "-retries", "5", "-workers", "3", "-rate-limit", "250ms",
+		"クレール住吉",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg := opts.browser(); !cfg.Headless || cfg.ProxyServer != "http://proxy:8080" || cfg.Locale != "ja-JP" {
+		t.Errorf("browser() = %+v", cfg)
+	}
+	if site := opts.site(); site.BBURL != "http://127.0.0.1:9000" || site.AccountsURL != DefaultSiteConfig().AccountsURL {
+		t.Errorf("site() = %+v", site)
+	}
+	if session := opts.session(); session.File != "s.enc" || session.ProfileDir != "" {
+		t.Errorf("session() = %+v", session)
+	}
+	pool := opts.poolOptions()
+	if pool.Workers != 3 || pool.MinInterval != 250*time.Millisecond || pool.Retry.MaxAttempts != 5 || pool.Selectors == nil {
+		t.Errorf("poolOptions() = %+v", pool)
+	}
+	if fs.Arg(0) != "クレール住吉" {
+		t.Errorf("argument = %q", fs.Arg(0))
+	}
+}
+
+func TestCommandsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, cmd := range commands() {
+		if seen[cmd.name] || cmd.run == nil {
+			t.Errorf("command %q duplicated or without run", cmd.name)
+		}
+		seen[cmd.name] = true
+	}
+}
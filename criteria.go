@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SearchCriteria は賃貸リスト検索の条件。空の項目は指定なし。
+type SearchCriteria struct {
+	PropertyName string `json:"property_name,omitempty"`
+
+	Prefecture   string `json:"prefecture,omitempty"`   // 大阪府
+	Municipality string `json:"municipality,omitempty"` // 大阪市住吉区
+	Station      string `json:"station,omitempty"`      // 長居
+	WalkMinutes  int    `json:"walk_minutes,omitempty"` // 駅徒歩N分以内
+
+	// RentMin and RentMax are in yen, management fee excluded
+	RentMin int `json:"rent_min,omitempty"`
+	RentMax int `json:"rent_max,omitempty"`
+
+	// Layouts are 間取り such as "1K" or "1LDK"; "ワンルーム" is "1R"
+	Layouts []string `json:"layouts,omitempty"`
+
+	// AreaMin and AreaMax are in square meters
+	AreaMin float64 `json:"area_min,omitempty"`
+	AreaMax float64 `json:"area_max,omitempty"`
+
+	// BuildingAgeMax is 築N年以内
+	BuildingAgeMax int `json:"building_age_max,omitempty"`
+
+	RecruitingOnly bool `json:"recruiting_only,omitempty"`
+
+	// Address and ManagementCompany are not entered in the form; they help
+	// pick the requested building among the results (see SelectBuilding)
+	Address           string `json:"address,omitempty"`
+	ManagementCompany string `json:"management_company,omitempty"`
+}
+
+// LoadSearchCriteria reads and validates search criteria from a JSON file
+func LoadSearchCriteria(path string) (SearchCriteria, error) {
+	var c SearchCriteria
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("failed to read search criteria: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("failed to parse search criteria %s: %w", path, err)
+	}
+	return c, c.Validate()
+}
+
+// Validate checks that something is searched for and that ranges are consistent
+func (c SearchCriteria) Validate() error {
+	var problems []string
+	if c.WalkMinutes < 0 || c.RentMin < 0 || c.RentMax < 0 || c.AreaMin < 0 || c.AreaMax < 0 || c.BuildingAgeMax < 0 {
+		problems = append(problems, "values must not be negative")
+	}
+	if c.RentMax > 0 && c.RentMin > c.RentMax {
+		problems = append(problems, fmt.Sprintf("rent_min %d is above rent_max %d", c.RentMin, c.RentMax))
+	}
+	if c.AreaMax > 0 && c.AreaMin > c.AreaMax {
+		problems = append(problems, fmt.Sprintf("area_min %g is above area_max %g", c.AreaMin, c.AreaMax))
+	}
+	for _, layout := range c.Layouts {
+		if _, err := ParseLayout(normalizeLayout(layout)); err != nil && normalizeLayout(layout) != "1R" {
+			problems = append(problems, fmt.Sprintf("unknown layout %q", layout))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid search criteria: %s", strings.Join(problems, "; "))
+	}
+	if strings.TrimSpace(c.PropertyName) == "" && len(c.formFields()) == 0 {
+		return errors.New("search criteria are empty")
+	}
+	return nil
+}
+
+// String describes the criteria in Japanese, e.g. for logs and result rows
+func (c SearchCriteria) String() string {
+	var parts []string
+	add := func(s string) {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+
+	add(c.PropertyName)
+	add(c.Prefecture + c.Municipality)
+	if c.Station != "" {
+		station := c.Station + "駅"
+		if c.WalkMinutes > 0 {
+			station += fmt.Sprintf("徒歩%d分以内", c.WalkMinutes)
+		}
+		add(station)
+	} else if c.WalkMinutes > 0 {
+		add(fmt.Sprintf("駅徒歩%d分以内", c.WalkMinutes))
+	}
+	if c.RentMin > 0 || c.RentMax > 0 {
+		add("賃料" + rangeText(manYenText(c.RentMin), manYenText(c.RentMax), "円"))
+	}
+	if len(c.Layouts) > 0 {
+		add(strings.Join(c.Layouts, "/"))
+	}
+	if c.AreaMin > 0 || c.AreaMax > 0 {
+		add(rangeText(areaText(c.AreaMin), areaText(c.AreaMax), "㎡"))
+	}
+	if c.BuildingAgeMax > 0 {
+		add(fmt.Sprintf("築%d年以内", c.BuildingAgeMax))
+	}
+	if c.RecruitingOnly {
+		add("募集中のみ")
+	}
+	return strings.Join(parts, " ")
+}
+
+// rangeText writes "min〜max" with the unit once, leaving out a missing bound
+func rangeText(lo, hi, unit string) string {
+	switch {
+	case lo != "" && hi != "":
+		return lo + "〜" + hi + unit
+	case lo != "":
+		return lo + unit + "以上"
+	default:
+		return hi + unit + "以下"
+	}
+}
+
+// manYenText writes yen in 万 ("8万", "7.5万"), or "" for 0
+func manYenText(yen int) string {
+	if yen == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(yen)/10000, 'f', -1, 64) + "万"
+}
+
+// areaText writes square meters without trailing zeros, or "" for 0
+func areaText(sqm float64) string {
+	if sqm == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(sqm, 'f', -1, 64)
+}
+
+// normalizeLayout reduces "１ldk" and "ワンルーム" to the form's "1LDK" and "1R"
+func normalizeLayout(layout string) string {
+	layout = strings.ToUpper(normalizeValue(layout))
+	if layout == "ワンルーム" {
+		return "1R"
+	}
+	return layout
+}
+
+// criteriaField is one control of the list search form to set. Param
+// replaces "{value}" in the field's selectors, so one selector list can
+// address each layout checkbox.
+type criteriaField struct {
+	Field  string
+	Param  string
+	Values []string // accepted values, in order; a select takes the first option matching any
+}
+
+// formFields maps the criteria onto the "criteria.*" selector fields. The
+// property name is entered separately through search.property_name_input.
+func (c SearchCriteria) formFields() []criteriaField {
+	var fields []criteriaField
+	add := func(field string, values ...string) {
+		fields = append(fields, criteriaField{Field: field, Values: values})
+	}
+
+	if c.Prefecture != "" {
+		add("criteria.prefecture", c.Prefecture)
+	}
+	if c.Municipality != "" {
+		add("criteria.municipality", c.Municipality)
+	}
+	if c.Station != "" {
+		add("criteria.station", c.Station)
+	}
+	if c.WalkMinutes > 0 {
+		m := strconv.Itoa(c.WalkMinutes)
+		add("criteria.walk_minutes", m, m+"分以内", m+"分")
+	}
+	if c.RentMin > 0 {
+		add("criteria.rent_min", yenValues(c.RentMin)...)
+	}
+	if c.RentMax > 0 {
+		add("criteria.rent_max", yenValues(c.RentMax)...)
+	}
+	for _, layout := range c.Layouts {
+		layout = normalizeLayout(layout)
+		fields = append(fields, criteriaField{Field: "criteria.layout", Param: layout, Values: []string{layout}})
+	}
+	if c.AreaMin > 0 {
+		add("criteria.area_min", areaValues(c.AreaMin)...)
+	}
+	if c.AreaMax > 0 {
+		add("criteria.area_max", areaValues(c.AreaMax)...)
+	}
+	if c.BuildingAgeMax > 0 {
+		y := strconv.Itoa(c.BuildingAgeMax)
+		add("criteria.building_age", y, y+"年以内", "築"+y+"年以内")
+	}
+	if c.RecruitingOnly {
+		add("criteria.recruiting_only", "true")
+	}
+
+	return fields
+}
+
+// yenValues are the ways a rent bound may appear in the form: 80000, 8万円, 8
+func yenValues(yen int) []string {
+	man := strconv.FormatFloat(float64(yen)/10000, 'f', -1, 64)
+	return []string{strconv.Itoa(yen), man + "万円", man}
+}
+
+// areaValues are the ways an area bound may appear in the form: 25, 25㎡
+func areaValues(sqm float64) []string {
+	a := areaText(sqm)
+	return []string{a, a + "㎡", a + "m²"}
+}
+
+// fillCriteria sets every criterion on the list search form. A criterion
+// whose control cannot be found fails the search rather than silently
+// widening it.
+func (s *ITANDIScraper) fillCriteria(c SearchCriteria) error {
+	for _, f := range c.formFields() {
+		if err := s.fillField(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillField sets one form control through the first of its selectors that works
+func (s *ITANDIScraper) fillField(f criteriaField) error {
+	for _, selector := range s.selectors.Get(f.Field) {
+		locator := strings.ReplaceAll(selector, "{value}", f.Param)
+		if err := fillLocator(s.ctx, locator, f.Values...); err == nil {
+			log.Printf("Set %s %s using selector: %s\n", f.Field, f.Values[0], locator)
+			return nil
+		}
+	}
+	return fmt.Errorf("could not set %s to %s: %w", f.Field, f.Values[0], s.selectorNotFound(f.Field))
+}
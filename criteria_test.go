@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchCriteriaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria SearchCriteria
+		wantErr  string
+	}{
+		{"name only", SearchCriteria{PropertyName: "クレール長居"}, ""},
+		{"area only", SearchCriteria{Prefecture: "大阪府", Municipality: "大阪市住吉区"}, ""},
+		{"recruiting only", SearchCriteria{RecruitingOnly: true}, ""},
+		{"one room", SearchCriteria{Layouts: []string{"ワンルーム", "１ｌｄｋ"}}, ""},
+		{"empty", SearchCriteria{}, "empty"},
+		{"blank name", SearchCriteria{PropertyName: "  "}, "empty"},
+		{"negative", SearchCriteria{RentMax: -1}, "negative"},
+		{"rent range", SearchCriteria{RentMin: 90000, RentMax: 80000}, "rent_min 90000 is above rent_max 80000"},
+		{"area range", SearchCriteria{AreaMin: 40, AreaMax: 25.5}, "area_min 40 is above area_max 25.5"},
+		{"open rent range", SearchCriteria{RentMin: 90000}, ""},
+		{"unknown layout", SearchCriteria{Layouts: []string{"1K", "広い"}}, `unknown layout "広い"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.criteria.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchCriteriaString(t *testing.T) {
+	tests := []struct {
+		criteria SearchCriteria
+		want     string
+	}{
+		{SearchCriteria{PropertyName: "クレール長居"}, "クレール長居"},
+		{
+			SearchCriteria{Prefecture: "大阪府", Municipality: "大阪市住吉区", Station: "長居", WalkMinutes: 10},
+			"大阪府大阪市住吉区 長居駅徒歩10分以内",
+		},
+		{SearchCriteria{WalkMinutes: 5}, "駅徒歩5分以内"},
+		{SearchCriteria{RentMin: 60000, RentMax: 75000}, "賃料6万〜7.5万円"},
+		{SearchCriteria{RentMax: 80000}, "賃料8万円以下"},
+		{SearchCriteria{RentMin: 50000}, "賃料5万円以上"},
+		{
+			SearchCriteria{Layouts: []string{"1K", "1LDK"}, AreaMin: 25, BuildingAgeMax: 20, RecruitingOnly: true},
+			"1K/1LDK 25㎡以上 築20年以内 募集中のみ",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.criteria.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSearchCriteriaFormFields(t *testing.T) {
+	c := SearchCriteria{
+		PropertyName:   "クレール長居",
+		Prefecture:     "大阪府",
+		WalkMinutes:    10,
+		RentMax:        75000,
+		Layouts:        []string{"ワンルーム", "1ldk"},
+		AreaMin:        25.5,
+		BuildingAgeMax: 20,
+		RecruitingOnly: true,
+	}
+	want := []criteriaField{
+		{Field: "criteria.prefecture", Values: []string{"大阪府"}},
+		{Field: "criteria.walk_minutes", Values: []string{"10", "10分以内", "10分"}},
+		{Field: "criteria.rent_max", Values: []string{"75000", "7.5万円", "7.5"}},
+		{Field: "criteria.layout", Param: "1R", Values: []string{"1R"}},
+		{Field: "criteria.layout", Param: "1LDK", Values: []string{"1LDK"}},
+		{Field: "criteria.area_min", Values: []string{"25.5", "25.5㎡", "25.5m²"}},
+		{Field: "criteria.building_age", Values: []string{"20", "20年以内", "築20年以内"}},
+		{Field: "criteria.recruiting_only", Values: []string{"true"}},
+	}
+
+	got := c.formFields()
+	if len(got) != len(want) {
+		t.Fatalf("formFields() returned %d fields, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Field != want[i].Field || got[i].Param != want[i].Param || !slices.Equal(got[i].Values, want[i].Values) {
+			t.Errorf("field %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCriteriaSelectorsConfigured(t *testing.T) {
+	store := NewSelectorStore()
+	for _, f := range (SearchCriteria{
+		Prefecture: "大阪府", Municipality: "大阪市", Station: "長居", WalkMinutes: 10,
+		RentMin: 50000, RentMax: 80000, Layouts: []string{"1K"}, AreaMin: 20, AreaMax: 40,
+		BuildingAgeMax: 20, RecruitingOnly: true,
+	}).formFields() {
+		if len(store.Get(f.Field)) == 0 {
+			t.Errorf("no selectors configured for %s", f.Field)
+		}
+	}
+}
+
+func TestLoadSearchCriteria(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "criteria.json")
+	data := `{"prefecture": "大阪府", "station": "長居", "rent_max": 80000, "layouts": ["1K", "1LDK"], "recruiting_only": true}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadSearchCriteria(path)
+	if err != nil {
+		t.Fatalf("LoadSearchCriteria: %v", err)
+	}
+	if c.Prefecture != "大阪府" || c.Station != "長居" || c.RentMax != 80000 || !c.RecruitingOnly || !slices.Equal(c.Layouts, []string{"1K", "1LDK"}) {
+		t.Errorf("criteria = %+v", c)
+	}
+
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSearchCriteria(path); err == nil {
+		t.Error("empty criteria file accepted")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ListingDetail は部屋の詳細ページから取得した情報
+type ListingDetail struct {
+	URL string `json:"url"`
+
+	Facilities          []string `json:"facilities,omitempty"`
+	ContractType        string   `json:"contract_type,omitempty"` // 普通借家 / 定期借家
+	ContractPeriod      string   `json:"contract_period,omitempty"`
+	RenewalFee          string   `json:"renewal_fee,omitempty"`
+	GuarantorCompany    string   `json:"guarantor_company,omitempty"`
+	KeyExchangeFee      int      `json:"key_exchange_fee,omitempty"`
+	PetCondition        string   `json:"pet_condition,omitempty"`
+	InstrumentCondition string   `json:"instrument_condition,omitempty"`
+	Direction           string   `json:"direction,omitempty"`
+	Structure           string   `json:"structure,omitempty"`
+	BuiltDate           string   `json:"built_date,omitempty"`
+	BuiltYear           int      `json:"built_year,omitempty"`
+
+	// Fields holds every label/value pair found on the page
+	Fields map[string]string `json:"fields"`
+}
+
+// detailLabels are the labels ITANDI BB detail pages use for each field, in
+// order of preference
+var detailLabels = map[string][]string{
+	"facilities":           {"設備", "室内設備", "部屋設備", "設備・サービス", "共用設備", "その他設備"},
+	"contract_type":        {"契約形態", "契約種別", "契約区分", "借家種別"},
+	"contract_period":      {"契約期間"},
+	"renewal_fee":          {"更新料"},
+	"guarantor_company":    {"保証会社", "家賃保証会社", "保証会社名"},
+	"key_exchange_fee":     {"鍵交換費用", "鍵交換代", "鍵交換費", "鍵交換"},
+	"pet_condition":        {"ペット", "ペット飼育", "ペット相談", "ペット可否"},
+	"instrument_condition": {"楽器", "楽器使用", "楽器相談", "楽器可否"},
+	"direction":            {"向き", "主要採光面", "方位", "バルコニー向き"},
+	"structure":            {"構造", "建物構造"},
+	"built_date":           {"築年月", "完成年月", "竣工年月", "建築年月"},
+
+	// Fields the search result card may lack
+	"address":            {"所在地", "住所"},
+	"layout":             {"間取り"},
+	"area":               {"専有面積", "面積"},
+	"floor":              {"所在階", "階数", "階"},
+	"available_date":     {"入居可能時期", "入居時期", "入居可能日"},
+	"management_company": {"管理会社"},
+}
+
+// builtYearPattern finds the western year of 築年月 ("2011年9月", "2011/09")
+var builtYearPattern = regexp.MustCompile(`(\d{4})\s*[年/.-]`)
+
+// detailField returns the value of the first label of key present in fields
+func detailField(fields map[string]string, key string) string {
+	for _, label := range detailLabels[key] {
+		if v := strings.TrimSpace(fields[label]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// newListingDetail maps the label/value pairs of a detail page to a ListingDetail
+func newListingDetail(url string, fields map[string]string) *ListingDetail {
+	d := &ListingDetail{
+		URL:                 url,
+		ContractType:        detailField(fields, "contract_type"),
+		ContractPeriod:      detailField(fields, "contract_period"),
+		RenewalFee:          detailField(fields, "renewal_fee"),
+		GuarantorCompany:    detailField(fields, "guarantor_company"),
+		PetCondition:        detailField(fields, "pet_condition"),
+		InstrumentCondition: detailField(fields, "instrument_condition"),
+		Direction:           detailField(fields, "direction"),
+		Structure:           detailField(fields, "structure"),
+		BuiltDate:           detailField(fields, "built_date"),
+		Fields:              fields,
+	}
+
+	// Several facility sections (室内設備, 共用設備) are combined
+	seen := make(map[string]bool)
+	for _, label := range detailLabels["facilities"] {
+		for _, f := range splitFacilities(fields[label]) {
+			if !seen[f] {
+				seen[f] = true
+				d.Facilities = append(d.Facilities, f)
+			}
+		}
+	}
+
+	if fee := detailField(fields, "key_exchange_fee"); fee != "" {
+		// Drop notes such as "（税込）" after the amount
+		if i := strings.Index(fee, "円"); i >= 0 {
+			fee = fee[:i+len("円")]
+		}
+		d.KeyExchangeFee, _ = ParseYen(fee)
+	}
+	if m := builtYearPattern.FindStringSubmatch(normalizeValue(d.BuiltDate)); m != nil {
+		d.BuiltYear, _ = strconv.Atoi(m[1])
+	}
+	return d
+}
+
+// splitFacilities splits a facility list written as "エアコン、オートロック／宅配ボックス"
+func splitFacilities(s string) []string {
+	var facilities []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune("、,，/／\n", r)
+	}) {
+		if f = strings.TrimSpace(f); f != "" {
+			facilities = append(facilities, f)
+		}
+	}
+	return facilities
+}
+
+// mergeDetail attaches the detail page to the listing and fills the fields
+// the search result card did not show
+func (l *PropertyListing) mergeDetail(d *ListingDetail) {
+	l.Detail = d
+	fields := d.Fields
+
+	if l.Address == "" {
+		l.Address = detailField(fields, "address")
+	}
+	if l.Layout == "" {
+		l.Layout = detailField(fields, "layout")
+		if layout, err := ParseLayout(l.Layout); err == nil {
+			l.LayoutDetail = &layout
+		}
+	}
+	if l.AreaSqm == 0 {
+		l.AreaSqm, _ = ParseArea(detailField(fields, "area"))
+	}
+	if l.Floor == 0 {
+		l.Floor, _ = ParseFloor(detailField(fields, "floor"))
+	}
+	if l.AvailableDate == "" {
+		l.AvailableDate = detailField(fields, "available_date")
+		if availability, err := ParseAvailability(l.AvailableDate, time.Now()); err == nil {
+			l.Availability = &availability
+		}
+	}
+	if l.ManagementCompany == "" {
+		l.ManagementCompany = detailField(fields, "management_company")
+	}
+}
+
+// UseDetailPages makes GetRoomDetails open the 詳細 page of every returned
+// listing in a new tab and merge its fields into the listing
+func (s *ITANDIScraper) UseDetailPages(enabled bool) {
+	s.detailPages = enabled
+}
+
+// fetchListingDetails reads the detail page of every listing with a URL. A
+// page that fails is logged and counted; the listing keeps its card fields.
+func (s *ITANDIScraper) fetchListingDetails(result *SearchResult) {
+	for i := range result.Listings {
+		l := &result.Listings[i]
+		if l.URL == "" {
+			continue
+		}
+		log.Printf("Reading detail page of %s %s: %s\n", l.Name, l.RoomNumber, l.URL)
+		detail, err := s.fetchListingDetail(l.URL)
+		if err != nil {
+			log.Printf("Warning: failed to read detail page of %s %s: %v\n", l.Name, l.RoomNumber, err)
+			result.Diagnostics.DetailPagesFailed++
+			continue
+		}
+		l.mergeDetail(detail)
+		result.Diagnostics.DetailPagesRead++
+	}
+}
+
+// fetchListingDetail opens url in a new tab of the same browser, so the
+// search results stay on screen, and extracts its label/value pairs
+func (s *ITANDIScraper) fetchListingDetail(url string) (*ListingDetail, error) {
+	tabCtx, closeTab := chromedp.NewContext(s.ctx)
+	defer closeTab()
+	ctx, cancel := context.WithTimeout(tabCtx, s.waits.Page)
+	defer cancel()
+
+	if err := chromedp.Run(ctx, chromedp.Navigate(url), chromedp.WaitReady("body")); err != nil {
+		return nil, fmt.Errorf("%w: detail page: %w", ErrNavigationFailed, err)
+	}
+	if s.site.IsLoginPage(currentURL(ctx)) {
+		return nil, fmt.Errorf("%w: detail page redirected to login", ErrSessionExpired)
+	}
+
+	var fields map[string]string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(detailPageScript, &fields)); err != nil {
+		return nil, fmt.Errorf("%w: detail page: %w", ErrExtractionFailed, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields on detail page", ErrExtractionFailed)
+	}
+	return newListingDetail(url, fields), nil
+}
+
+// currentURL returns the URL of the tab, or "" when it cannot be read
+func currentURL(ctx context.Context) string {
+	var url string
+	chromedp.Run(ctx, chromedp.Location(&url))
+	return url
+}
+
+// detailPageScript collects the label/value pairs of a detail page from
+// tables, definition lists and headed lists (such as the 設備 list)
+const detailPageScript = `
+(() => {
+	const fields = {};
+	const clean = s => (s || '').replace(/\s+/g, ' ').trim();
+	const add = (label, value) => {
+		label = clean(label).replace(/[:：]$/, '');
+		value = clean(value);
+		if (label && value && !(label in fields)) {
+			fields[label] = value;
+		}
+	};
+
+	document.querySelectorAll('tr').forEach(row => {
+		const cells = row.querySelectorAll('th, td');
+		// Rows may hold two pairs: 賃料 | 7.7万円 | 管理費 | 5,000円
+		for (let i = 0; i + 1 < cells.length; i += 2) {
+			add(cells[i].textContent, cells[i + 1].textContent);
+		}
+	});
+
+	document.querySelectorAll('dl').forEach(dl => {
+		const dts = dl.querySelectorAll('dt');
+		const dds = dl.querySelectorAll('dd');
+		for (let i = 0; i < Math.min(dts.length, dds.length); i++) {
+			add(dts[i].textContent, dds[i].textContent);
+		}
+	});
+
+	document.querySelectorAll('h2, h3, h4').forEach(h => {
+		const list = h.nextElementSibling;
+		if (list && (list.tagName === 'UL' || list.tagName === 'OL')) {
+			add(h.textContent, Array.from(list.querySelectorAll('li')).map(li => clean(li.textContent)).join('、'));
+		}
+	});
+
+	return fields;
+})()
+`
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewListingDetail(t *testing.T) {
+	fields := map[string]string{
+		"契約形態":   "定期借家",
+		"契約期間":   "3年",
+		"更新料":    "なし",
+		"家賃保証会社": "日本賃貸保証",
+		"鍵交換代":   "16,500円（税込）",
+		"ペット相談":  "不可",
+		"楽器":     "ピアノ可",
+		"主要採光面":  "南",
+		"建物構造":   "鉄骨造",
+		"完成年月":   "２００８年３月",
+		"室内設備":   "エアコン、独立洗面台／浴室乾燥機",
+		"共用設備":   "オートロック、エアコン",
+	}
+	d := newListingDetail("https://itandibb.com/rent_rooms/1", fields)
+
+	if d.ContractType != "定期借家" || d.ContractPeriod != "3年" || d.RenewalFee != "なし" {
+		t.Errorf("contract = %q %q %q", d.ContractType, d.ContractPeriod, d.RenewalFee)
+	}
+	if d.GuarantorCompany != "日本賃貸保証" || d.KeyExchangeFee != 16500 {
+		t.Errorf("guarantor = %q, key exchange = %d", d.GuarantorCompany, d.KeyExchangeFee)
+	}
+	if d.PetCondition != "不可" || d.InstrumentCondition != "ピアノ可" {
+		t.Errorf("pets = %q, instruments = %q", d.PetCondition, d.InstrumentCondition)
+	}
+	if d.Direction != "南" || d.Structure != "鉄骨造" || d.BuiltYear != 2008 {
+		t.Errorf("direction = %q, structure = %q, built year = %d", d.Direction, d.Structure, d.BuiltYear)
+	}
+	want := []string{"エアコン", "独立洗面台", "浴室乾燥機", "オートロック"}
+	if !slices.Equal(d.Facilities, want) {
+		t.Errorf("Facilities = %v, want %v", d.Facilities, want)
+	}
+}
+
+func TestMergeDetailFillsMissingFields(t *testing.T) {
+	l := PropertyListing{Name: "クレール住吉", RoomNumber: "302", Layout: "1LDK", Floor: 3}
+	l.mergeDetail(newListingDetail("", map[string]string{
+		"所在地":  "大阪府大阪市住吉区長居1-2-3",
+		"間取り":  "2DK",
+		"専有面積": "44.61㎡",
+		"所在階":  "5階",
+		"管理会社": "株式会社Room",
+	}))
+
+	if l.Detail == nil {
+		t.Fatal("Detail not attached")
+	}
+	if l.Address != "大阪府大阪市住吉区長居1-2-3" || l.AreaSqm != 44.61 || l.ManagementCompany != "株式会社Room" {
+		t.Errorf("missing fields not filled: %+v", l)
+	}
+	// Values shown on the search result card are kept
+	if l.Layout != "1LDK" || l.Floor != 3 {
+		t.Errorf("card fields overwritten: layout = %q, floor = %d", l.Layout, l.Floor)
+	}
+}
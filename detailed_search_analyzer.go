@@ -7,11 +7,11 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-func analyzeDetailedSearch() {
+func analyzeDetailedSearch(cfg BrowserConfig, site SiteConfig, propertyName string) {
 	log.Println("=== Detailed Search Result Analysis ===")
 	
-	// Create scraper instance with visible browser
-	scraper, err := NewITANDIScraper(false)
+	// Create scraper instance (visible unless -headless)
+	scraper, err := NewITANDIScraperWithConfig(cfg, site, SessionOptions{})
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
@@ -31,7 +31,7 @@ func analyzeDetailedSearch() {
 	time.Sleep(5 * time.Second)
 	
 	// Search for a property
-	if err := scraper.SearchProperty("クレール立川"); err != nil {
+	if err := scraper.SearchProperty(propertyName); err != nil {
 		log.Fatal("Failed to search:", err)
 	}
 
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// listingNotListed is the status shown for a room missing from the results
+const listingNotListed = "掲載なし"
+
+// ListingRef identifies a room in a diff
+type ListingRef struct {
+	Name       string `json:"name"`
+	RoomNumber string `json:"room_number,omitempty"`
+}
+
+// String returns "302号室" or the listing name when there is no room number
+func (r ListingRef) String() string {
+	if r.RoomNumber != "" {
+		return strings.TrimSuffix(r.RoomNumber, "号室") + "号室"
+	}
+	return r.Name
+}
+
+// StatusChange is a room whose 募集状況 changed
+type StatusChange struct {
+	ListingRef
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// FieldChange is a changed condition of a room that is listed in both runs
+type FieldChange struct {
+	ListingRef
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// ListingDiff は前回の確認からの変更
+type ListingDiff struct {
+	PropertyName string    `json:"property_name"`
+	RoomNumber   string    `json:"room_number,omitempty"`
+	PreviousAt   time.Time `json:"previous_at"`
+	CurrentAt    time.Time `json:"current_at"`
+
+	// NewRooms were not listed in the previous run
+	NewRooms []PropertyListing `json:"new_rooms"`
+
+	// NoLongerRecruiting were 募集中 before and now have another status or are gone
+	NoLongerRecruiting []StatusChange `json:"no_longer_recruiting"`
+
+	// StatusChanges are the other status changes, such as 申込あり back to 募集中
+	StatusChanges []StatusChange `json:"status_changes"`
+
+	// Changes are rent, fee, deposit, key money and availability changes
+	Changes []FieldChange `json:"changes"`
+}
+
+// diffFields are the compared conditions, in summary order
+var diffFields = []struct {
+	field string
+	label string
+	value func(PropertyListing) any
+}{
+	{"rent", "賃料", func(l PropertyListing) any { return l.Rent }},
+	{"management_fee", "管理費", func(l PropertyListing) any { return l.ManagementFee }},
+	{"deposit", "敷金", func(l PropertyListing) any { return l.Deposit }},
+	{"key_money", "礼金", func(l PropertyListing) any { return l.KeyMoney }},
+	{"available_date", "入居時期", func(l PropertyListing) any { return l.AvailableDate }},
+}
+
+// HasChanges reports whether anything changed between the runs
+func (d *ListingDiff) HasChanges() bool {
+	return len(d.NewRooms) > 0 || len(d.NoLongerRecruiting) > 0 || len(d.StatusChanges) > 0 || len(d.Changes) > 0
+}
+
+// DiffListings compares the listings of two runs room by room
+func DiffListings(previous, current []PropertyListing) ListingDiff {
+	diff := ListingDiff{
+		NewRooms:           []PropertyListing{},
+		NoLongerRecruiting: []StatusChange{},
+		StatusChanges:      []StatusChange{},
+		Changes:            []FieldChange{},
+	}
+
+	before := make(map[string]PropertyListing, len(previous))
+	for _, l := range previous {
+		before[listingKey(l)] = l
+	}
+	seen := make(map[string]bool, len(current))
+
+	for _, cur := range current {
+		key := listingKey(cur)
+		seen[key] = true
+		prev, ok := before[key]
+		if !ok {
+			diff.NewRooms = append(diff.NewRooms, cur)
+			continue
+		}
+
+		ref := ListingRef{Name: cur.Name, RoomNumber: cur.RoomNumber}
+		if prev.Status != cur.Status {
+			change := StatusChange{ListingRef: ref, Before: prev.Status, After: cur.Status}
+			if prev.Recruiting() {
+				diff.NoLongerRecruiting = append(diff.NoLongerRecruiting, change)
+			} else {
+				diff.StatusChanges = append(diff.StatusChanges, change)
+			}
+		}
+		for _, f := range diffFields {
+			if b, a := f.value(prev), f.value(cur); b != a {
+				diff.Changes = append(diff.Changes, FieldChange{ListingRef: ref, Field: f.field, Before: b, After: a})
+			}
+		}
+	}
+
+	for _, prev := range previous {
+		if seen[listingKey(prev)] {
+			continue
+		}
+		change := StatusChange{
+			ListingRef: ListingRef{Name: prev.Name, RoomNumber: prev.RoomNumber},
+			Before:     prev.Status,
+			After:      listingNotListed,
+		}
+		if prev.Recruiting() {
+			diff.NoLongerRecruiting = append(diff.NoLongerRecruiting, change)
+		} else {
+			diff.StatusChanges = append(diff.StatusChanges, change)
+		}
+	}
+
+	return diff
+}
+
+// DiffRuns compares two recorded runs of the same property
+func DiffRuns(previous, current ConfirmationRun) ListingDiff {
+	diff := DiffListings(previous.Listings, current.Listings)
+	diff.PropertyName = current.PropertyName
+	diff.RoomNumber = current.RoomNumber
+	diff.PreviousAt = previous.ConfirmedAt
+	diff.CurrentAt = current.ConfirmedAt
+	return diff
+}
+
+// listingKey matches a room across runs by building name and normalized room number
+func listingKey(l PropertyListing) string {
+	name := strings.Join(strings.Fields(normalizeValue(l.Name)), "")
+	if l.RoomNumber == "" {
+		return name + "#" + l.URL
+	}
+	return name + "#" + normalizeRoomNumber(l.RoomNumber)
+}
+
+// Summary returns the diff as readable Japanese text
+func (d *ListingDiff) Summary() string {
+	var b strings.Builder
+	title := d.PropertyName
+	if d.RoomNumber != "" {
+		title += " " + ListingRef{RoomNumber: d.RoomNumber}.String()
+	}
+	fmt.Fprintf(&b, "%s: 前回（%s）からの変更\n", title, d.PreviousAt.Local().Format("2006-01-02 15:04"))
+
+	if !d.HasChanges() {
+		b.WriteString("- 変更なし\n")
+		return b.String()
+	}
+	for _, l := range d.NewRooms {
+		fmt.Fprintf(&b, "- 新規掲載: %s %s %s\n", ListingRef{Name: l.Name, RoomNumber: l.RoomNumber}, formatYen(l.Rent), l.Status)
+	}
+	for _, c := range d.NoLongerRecruiting {
+		fmt.Fprintf(&b, "- 募集終了: %s（%s → %s）\n", c.ListingRef, c.Before, c.After)
+	}
+	for _, c := range d.StatusChanges {
+		fmt.Fprintf(&b, "- 募集状況: %s（%s → %s）\n", c.ListingRef, c.Before, c.After)
+	}
+	for _, c := range d.Changes {
+		fmt.Fprintf(&b, "- %s: %s %s → %s\n", diffFieldLabel(c.Field), c.ListingRef, formatDiffValue(c.Before), formatDiffValue(c.After))
+	}
+	return b.String()
+}
+
+// diffFieldLabel returns the Japanese label of a compared field
+func diffFieldLabel(field string) string {
+	for _, f := range diffFields {
+		if f.field == field {
+			return f.label
+		}
+	}
+	return field
+}
+
+// formatDiffValue formats yen amounts with separators and leaves text as is
+func formatDiffValue(v any) string {
+	switch v := v.(type) {
+	case int:
+		return formatYen(v)
+	case float64:
+		// values decoded from JSON
+		return formatYen(int(v))
+	case string:
+		if v == "" {
+			return "未設定"
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// formatYen formats 77000 as "77,000円"
+func formatYen(yen int) string {
+	s := fmt.Sprint(yen)
+	if yen < 0 {
+		return "-" + formatYen(-yen)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s + "円"
+}
+
+// previousSnapshot returns the diff between res and the last complete run of
+// the same property and room, or nil when there is none. Incomplete and
+// ambiguous runs are not compared: rooms on unread pages would show as 掲載なし
+// and other buildings' rooms as new.
+func previousSnapshot(store *HistoryStore, res BatchResult) (*ListingDiff, error) {
+	if res.Status == BatchStatusError || res.Status == BatchStatusAmbiguousMatch || res.Result == nil || res.Result.Incomplete {
+		return nil, nil
+	}
+	previous, err := store.LatestRun(res.PropertyName, res.RoomNumber)
+	if err != nil || previous == nil {
+		return nil, err
+	}
+	diff := DiffRuns(*previous, ConfirmationRun{
+		PropertyName: res.PropertyName,
+		RoomNumber:   res.RoomNumber,
+		ConfirmedAt:  res.ConfirmedAt,
+		Listings:     res.Result.Listings,
+	})
+	return &diff, nil
+}
+
+// runDiff implements the diff subcommand, comparing the two latest runs of a property
+func runDiff(args []string) {
+	fs := newFlagSet("diff", "[flags]", "Compare the two latest successful confirmations of a property.")
+	dbPath := fs.String("db", defaultHistoryDB, "History database file")
+	propertyName := fs.String("property", "", "Property name exactly as confirmed")
+	room := fs.String("room", "", "Room number the property was confirmed with")
+	asJSON := fs.Bool("json", false, "Print the diff as JSON")
+	fs.Parse(args)
+
+	if *propertyName == "" {
+		log.Fatal("-property is required")
+	}
+	if _, err := os.Stat(*dbPath); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("History database %s not found - confirmations are recorded there unless -db is empty", *dbPath)
+	}
+	store, err := OpenHistoryStore(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	runs, err := store.LatestRuns(*propertyName, *room, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(runs) < 2 {
+		log.Fatalf("Need two successful confirmations of %s %s to compare, found %d", *propertyName, *room, len(runs))
+	}
+
+	diff := DiffRuns(runs[1], runs[0])
+	if *asJSON {
+		jsonData, _ := json.MarshalIndent(diff, "", "  ")
+		fmt.Println(string(jsonData))
+		return
+	}
+	fmt.Print(diff.Summary())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffListings(t *testing.T) {
+	previous := []PropertyListing{
+		{Name: "クレール住吉", RoomNumber: "302", Status: "募集中", Rent: 77000, ManagementFee: 5000},
+		{Name: "クレール住吉", RoomNumber: "101", Status: "募集中", Rent: 70000},
+		{Name: "クレール住吉", RoomNumber: "201", Status: "申込あり", Rent: 72000},
+		{Name: "クレール住吉", RoomNumber: "401", Status: "募集中", Rent: 80000},
+	}
+	current := []PropertyListing{
+		{Name: "クレール住吉", RoomNumber: "３０２号室", Status: "募集中", Rent: 75000, ManagementFee: 5000},
+		{Name: "クレール住吉", RoomNumber: "101", Status: "申込あり", Rent: 70000},
+		{Name: "クレール住吉", RoomNumber: "201", Status: "募集中", Rent: 72000},
+		{Name: "クレール住吉", RoomNumber: "502", Status: "募集中", Rent: 90000},
+	}
+
+	diff := DiffListings(previous, current)
+	if len(diff.NewRooms) != 1 || diff.NewRooms[0].RoomNumber != "502" {
+		t.Errorf("NewRooms = %+v", diff.NewRooms)
+	}
+	if len(diff.NoLongerRecruiting) != 2 ||
+		diff.NoLongerRecruiting[0].RoomNumber != "101" || diff.NoLongerRecruiting[0].After != "申込あり" ||
+		diff.NoLongerRecruiting[1].RoomNumber != "401" || diff.NoLongerRecruiting[1].After != listingNotListed {
+		t.Errorf("NoLongerRecruiting = %+v", diff.NoLongerRecruiting)
+	}
+	if len(diff.StatusChanges) != 1 || diff.StatusChanges[0].RoomNumber != "201" {
+		t.Errorf("StatusChanges = %+v", diff.StatusChanges)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "rent" || diff.Changes[0].Before != 77000 || diff.Changes[0].After != 75000 {
+		t.Errorf("Changes = %+v", diff.Changes)
+	}
+
+	summary := diff.Summary()
+	for _, want := range []string{
+		"新規掲載: 502号室 90,000円 募集中",
+		"募集終了: 101号室（募集中 → 申込あり）",
+		"募集終了: 401号室（募集中 → 掲載なし）",
+		"賃料: ３０２号室 77,000円 → 75,000円",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+
+	if same := DiffListings(previous, previous); same.HasChanges() {
+		t.Errorf("identical runs reported changes: %+v", same)
+	}
+}
+
+func TestRecordHistoryAttachesDiff(t *testing.T) {
+	store, err := OpenHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	first := historyResult("クレール住吉", day, [2]string{"302", "募集中"})
+	recordHistory(store, &first, SourceCLI)
+	if first.Diff != nil {
+		t.Errorf("first run should have no diff: %+v", first.Diff)
+	}
+
+	// A failed run in between is not a snapshot to compare against
+	failed := BatchResult{BatchItem: BatchItem{PropertyName: "クレール住吉"}, ConfirmedAt: day.Add(time.Hour)}
+	failed.setError(ErrTimeout)
+	recordHistory(store, &failed, SourceCLI)
+
+	second := historyResult("クレール住吉", day.AddDate(0, 0, 1), [2]string{"302", "申込あり"})
+	second.Screenshot = "/tmp/screenshots/search_results_20260402_090000_2.png"
+	recordHistory(store, &second, SourceCLI)
+	if second.Diff == nil || !second.Diff.PreviousAt.Equal(day) || len(second.Diff.NoLongerRecruiting) != 1 {
+		t.Fatalf("Diff = %+v", second.Diff)
+	}
+
+	runs, err := store.LatestRuns("クレール住吉", "", 2)
+	if err != nil {
+		t.Fatalf("LatestRuns: %v", err)
+	}
+	if len(runs) != 2 || !runs[0].ConfirmedAt.Equal(day.AddDate(0, 0, 1)) || len(runs[1].Listings) != 1 {
+		t.Fatalf("LatestRuns = %+v", runs)
+	}
+	// Each run keeps its own screenshot
+	if runs[0].ScreenshotPath != second.Screenshot || runs[1].ScreenshotPath != "" {
+		t.Errorf("screenshots = %q, %q", runs[0].ScreenshotPath, runs[1].ScreenshotPath)
+	}
+}
+
+func TestRecordHistorySkipsIncompleteAndAmbiguousRuns(t *testing.T) {
+	store, err := OpenHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	first := historyResult("クレール住吉", day, [2]string{"101", "募集中"}, [2]string{"302", "募集中"})
+	recordHistory(store, &first, SourceSchedule)
+
+	// A result page failed to load, so room 302 was never read
+	truncated := historyResult("クレール住吉", day.Add(time.Hour), [2]string{"101", "募集中"})
+	truncated.Result.Incomplete = true
+	recordHistory(store, &truncated, SourceSchedule)
+	if truncated.Diff != nil {
+		t.Errorf("incomplete run was diffed: %+v", truncated.Diff)
+	}
+
+	// Listings of every candidate building, including another building's room
+	ambiguous := BatchResult{BatchItem: BatchItem{PropertyName: "クレール住吉"}, ConfirmedAt: day.Add(2 * time.Hour)}
+	ambiguous.setResult(&SearchResult{
+		Status: SearchStatusAmbiguousMatch,
+		Match:  &NameMatch{Requested: "クレール住吉", Name: "クレール住吉公園", Score: 0.6, Ambiguous: true},
+		Listings: []PropertyListing{
+			{Name: "クレール住吉", RoomNumber: "101", Status: "募集中"},
+			{Name: "クレール住吉公園", RoomNumber: "502", Status: "募集中"},
+		},
+	})
+	recordHistory(store, &ambiguous, SourceSchedule)
+	if ambiguous.Diff != nil {
+		t.Errorf("ambiguous run was diffed: %+v", ambiguous.Diff)
+	}
+
+	// The next complete run is compared with the last complete one
+	second := historyResult("クレール住吉", day.AddDate(0, 0, 1), [2]string{"101", "募集中"}, [2]string{"302", "募集中"})
+	recordHistory(store, &second, SourceSchedule)
+	if second.Diff == nil || !second.Diff.PreviousAt.Equal(day) || second.Diff.HasChanges() {
+		t.Errorf("Diff = %+v, want no changes since the first run", second.Diff)
+	}
+
+	runs, err := store.LatestRuns("クレール住吉", "", 5)
+	if err != nil {
+		t.Fatalf("LatestRuns: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Incomplete || runs[1].Incomplete {
+		t.Errorf("LatestRuns = %+v, want only the two complete runs", runs)
+	}
+
+	all, err := store.Runs(HistoryQuery{PropertyName: "クレール住吉"})
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(all) != 4 || !all[1].Incomplete || all[2].Status != BatchStatusAmbiguousMatch {
+		t.Errorf("Runs = %+v", all)
+	}
+}
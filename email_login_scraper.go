@@ -14,26 +14,27 @@ import (
 type EmailLoginScraper struct {
 	ctx    context.Context
 	cancel context.CancelFunc
+	site   SiteConfig
 }
 
 // NewEmailLoginScraper creates a new email login scraper
 func NewEmailLoginScraper(headless bool) (*EmailLoginScraper, error) {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath("/Applications/Chromium.app/Contents/MacOS/Chromium"),
-		chromedp.Flag("headless", headless),
-	)
-
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, cancel2 := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	cfg := DefaultBrowserConfig()
+	cfg.Headless = headless
+	return NewEmailLoginScraperWithConfig(cfg, DefaultSiteConfig())
+}
 
-	combinedCancel := func() {
-		cancel2()
-		cancel()
+// NewEmailLoginScraperWithConfig creates a new email login scraper from a browser config and site endpoints
+func NewEmailLoginScraperWithConfig(cfg BrowserConfig, site SiteConfig) (*EmailLoginScraper, error) {
+	ctx, cancel, err := newBrowserContext(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	return &EmailLoginScraper{
 		ctx:    ctx,
-		cancel: combinedCancel,
+		cancel: cancel,
+		site:   site,
 	}, nil
 }
 
@@ -50,7 +51,7 @@ func (s *EmailLoginScraper) FindEmailLoginForm() error {
 	log.Println("Strategy 1: Looking for login buttons/links...")
 	
 	err := chromedp.Run(s.ctx,
-		chromedp.Navigate("https://itandi-accounts.com/"),
+		chromedp.Navigate(s.site.LoginURL()),
 		chromedp.WaitReady("body"),
 	)
 	if err != nil {
@@ -94,9 +95,7 @@ func (s *EmailLoginScraper) FindEmailLoginForm() error {
 
 	for _, selector := range loginSelectors {
 		log.Printf("Trying selector: %s\n", selector)
-		err = chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err = clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Clicked on: %s\n", selector)
 			time.Sleep(3 * time.Second)
@@ -131,16 +130,10 @@ func (s *EmailLoginScraper) FindEmailLoginForm() error {
 	}
 
 	// Strategy 4: Try common login URLs with different approaches
-	alternativeURLs := []string{
-		"https://accounts.itandi.com/",
-		"https://login.itandi.com/",
-		"https://auth.itandi.com/",
-		"https://itandi.com/login",
-		"https://app.itandi.com/login",
-		"https://bukkakun.com/auth/login",
-	}
-
-	for _, url := range alternativeURLs {
+	for _, url := range s.site.LoginCandidateURLs() {
+		if url == s.site.LoginURL() {
+			continue
+		}
 		log.Printf("Trying alternative URL: %s\n", url)
 		err = chromedp.Run(s.ctx,
 			chromedp.Navigate(url),
@@ -194,9 +187,7 @@ func (s *EmailLoginScraper) PerformEmailLogin(email, password string) error {
 
 	var emailFilled bool
 	for _, selector := range emailSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.SendKeys(selector, email, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := sendKeysLocator(s.ctx, selector, email)
 		if err == nil {
 			log.Printf("Email entered using selector: %s\n", selector)
 			emailFilled = true
@@ -219,9 +210,7 @@ func (s *EmailLoginScraper) PerformEmailLogin(email, password string) error {
 
 	var passwordFilled bool
 	for _, selector := range passwordSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.SendKeys(selector, password, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := sendKeysLocator(s.ctx, selector, password)
 		if err == nil {
 			log.Printf("Password entered using selector: %s\n", selector)
 			passwordFilled = true
@@ -246,9 +235,7 @@ func (s *EmailLoginScraper) PerformEmailLogin(email, password string) error {
 
 	var submitted bool
 	for _, selector := range submitSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Submit button clicked: %s\n", selector)
 			submitted = true
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Scraper failure classes. Returned errors wrap one of these so callers can
+// decide with errors.Is whether to retry, skip the property or alert.
+var (
+	// ErrLoginRejected means the site refused the credentials
+	ErrLoginRejected = errors.New("login rejected")
+
+	// ErrPhoneVerificationRequired means the account uses the phone verification login
+	ErrPhoneVerificationRequired = errors.New("phone verification required")
+
+	// ErrSelectorNotFound means none of the selectors for a field matched; see SelectorNotFoundError
+	ErrSelectorNotFound = errors.New("selector not found")
+
+	// ErrNoResults means the search returned no listings
+	ErrNoResults = errors.New("no results")
+
+	// ErrRoomNotListed means the building was found but the requested room is not listed
+	ErrRoomNotListed = errors.New("room not listed")
+
+	// ErrAmbiguousMatch means the best building matched the requested name weakly or tied with another
+	ErrAmbiguousMatch = errors.New("ambiguous match")
+
+	// ErrSessionExpired means ITANDI BB sent us back to the login page
+	ErrSessionExpired = errors.New("session expired")
+
+	// ErrModalBlocking means an advertisement modal could not be closed
+	ErrModalBlocking = errors.New("modal blocking the page")
+
+	// ErrTimeout means a page did not reach the expected state in time; see WaitTimeoutError
+	ErrTimeout = errors.New("timed out")
+
+	// ErrNavigationFailed means the browser could not load or reload a page
+	ErrNavigationFailed = errors.New("navigation failed")
+
+	// ErrExtractionFailed means the in-page script reading the results failed
+	ErrExtractionFailed = errors.New("extraction failed")
+
+	// ErrIncompleteResults means fewer listings were read than the result count, so a missing room is not conclusive
+	ErrIncompleteResults = errors.New("incomplete results")
+)
+
+// SelectorNotFoundError reports the logical field whose selectors all failed
+type SelectorNotFoundError struct {
+	Field string
+	Tried int
+}
+
+func (e *SelectorNotFoundError) Error() string {
+	return fmt.Sprintf("%s: none of %d selectors matched", e.Field, e.Tried)
+}
+
+func (e *SelectorNotFoundError) Is(target error) bool {
+	return target == ErrSelectorNotFound
+}
+
+// errorCodes maps the failure classes to the codes written to batch results
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrLoginRejected, "login_rejected"},
+	{ErrPhoneVerificationRequired, "phone_verification_required"},
+	{ErrSessionExpired, "session_expired"},
+	{ErrModalBlocking, "modal_blocking"},
+	{ErrSelectorNotFound, "selector_not_found"},
+	{ErrNoResults, "no_results"},
+	{ErrRoomNotListed, "room_not_listed"},
+	{ErrAmbiguousMatch, "ambiguous_match"},
+	{ErrTimeout, "timeout"},
+	{ErrNavigationFailed, "navigation_failed"},
+	{ErrExtractionFailed, "extraction_failed"},
+	{ErrIncompleteResults, "incomplete_results"},
+	{context.DeadlineExceeded, "timeout"},
+}
+
+// ErrorCode returns a stable snake_case code for err's failure class, or
+// "unknown" when it does not belong to one
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "unknown"
+}
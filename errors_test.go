@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("login: %w", ErrLoginRejected), "login_rejected"},
+		{fmt.Errorf("%w - use -updated flag", ErrPhoneVerificationRequired), "phone_verification_required"},
+		{fmt.Errorf("could not submit search: %w", &SelectorNotFoundError{Field: "search.submit_button", Tried: 7}), "selector_not_found"},
+		{&WaitTimeoutError{Step: "search results", Condition: "network idle"}, "timeout"},
+		{fmt.Errorf("navigate: %w", context.DeadlineExceeded), "timeout"},
+		{(&SearchResult{Status: SearchStatusNoResults}).Err(), "no_results"},
+		{(&SearchResult{Status: SearchStatusRoomNotListed, Room: "302"}).Err(), "room_not_listed"},
+		{(&SearchResult{Status: SearchStatusAmbiguousMatch, Match: &NameMatch{Name: "クレール", Ambiguous: true}}).Err(), "ambiguous_match"},
+		{fmt.Errorf("%w: top page: %w", ErrNavigationFailed, errors.New("net::ERR_CONNECTION_RESET")), "navigation_failed"},
+		{fmt.Errorf("%w: property data: %w", ErrExtractionFailed, errors.New("TypeError")), "extraction_failed"},
+		{errors.New("something else"), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorNotFoundError(t *testing.T) {
+	err := fmt.Errorf("could not fill email field: %w", &SelectorNotFoundError{Field: "login.email", Tried: 5})
+
+	if !errors.Is(err, ErrSelectorNotFound) {
+		t.Fatal("errors.Is(err, ErrSelectorNotFound) = false")
+	}
+	var notFound *SelectorNotFoundError
+	if !errors.As(err, &notFound) || notFound.Field != "login.email" {
+		t.Fatalf("errors.As did not recover the field: %v", err)
+	}
+}
+
+func TestWaitTimeoutIsErrTimeout(t *testing.T) {
+	lastErr := errors.New("execution context was destroyed")
+	err := fmt.Errorf("search results did not load: %w", &WaitTimeoutError{Step: "search results", LastErr: lastErr})
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Error("errors.Is(err, ErrTimeout) = false")
+	}
+	if !errors.Is(err, lastErr) {
+		t.Error("errors.Is(err, lastErr) = false")
+	}
+}
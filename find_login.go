@@ -9,27 +9,18 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-func findLoginPage() {
+func findLoginPage(cfg BrowserConfig, site SiteConfig) {
 	log.Println("=== Searching for Email/Password Login Page ===")
 	
-	// Create scraper instance with visible browser
-	scraper, err := NewITANDIScraper(false)
+	// Create scraper instance (visible unless -headless)
+	scraper, err := NewITANDIScraperWithConfig(cfg, site, SessionOptions{})
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
 	defer scraper.Close()
 
 	// Try different potential login URLs
-	loginURLs := []string{
-		"https://itandi-accounts.com/",
-		"https://itandi-accounts.com/login",
-		"https://itandi-accounts.com/sign_in",
-		"https://bukkakun.com/login",
-		"https://bukkakun.com/sign_in",
-		"https://bukkakun.com/users/sign_in",
-		"https://itandi.co.jp/login",
-		"https://accounts.itandi.co.jp/",
-	}
+	loginURLs := site.LoginCandidateURLs()
 
 	for i, url := range loginURLs {
 		log.Printf("\n=== Testing URL %d: %s ===\n", i+1, url)
@@ -94,7 +85,9 @@ func findLoginPage() {
 	log.Println("\n=== Search Complete ===")
 	log.Println("Check the generated screenshots and HTML files for login forms")
 	
-	// Keep browser open for manual inspection
-	log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
-	time.Sleep(30 * time.Second)
+	// Keep browser open for manual inspection if not headless
+	if !cfg.Headless {
+		log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
+		time.Sleep(30 * time.Second)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// historySchema creates the tables on first use
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	property_name   TEXT NOT NULL,
+	room_number     TEXT NOT NULL DEFAULT '',
+	source          TEXT NOT NULL DEFAULT '',
+	status          TEXT NOT NULL,
+	error_code      TEXT NOT NULL DEFAULT '',
+	error           TEXT NOT NULL DEFAULT '',
+	result_count    INTEGER NOT NULL DEFAULT 0,
+	page_url        TEXT NOT NULL DEFAULT '',
+	dom_path        TEXT NOT NULL DEFAULT '',
+	screenshot_path TEXT NOT NULL DEFAULT '',
+	incomplete      INTEGER NOT NULL DEFAULT 0,
+	confirmed_at    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_property ON runs (property_name, confirmed_at);
+
+CREATE TABLE IF NOT EXISTS listings (
+	id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id             INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	name               TEXT NOT NULL DEFAULT '',
+	room_number        TEXT NOT NULL DEFAULT '',
+	address            TEXT NOT NULL DEFAULT '',
+	url                TEXT NOT NULL DEFAULT '',
+	rent               INTEGER NOT NULL DEFAULT 0,
+	management_fee     INTEGER NOT NULL DEFAULT 0,
+	deposit            INTEGER NOT NULL DEFAULT 0,
+	key_money          INTEGER NOT NULL DEFAULT 0,
+	area_sqm           REAL NOT NULL DEFAULT 0,
+	floor              INTEGER NOT NULL DEFAULT 0,
+	layout             TEXT NOT NULL DEFAULT '',
+	available_date     TEXT NOT NULL DEFAULT '',
+	status             TEXT NOT NULL DEFAULT '',
+	management_company TEXT NOT NULL DEFAULT '',
+	raw                TEXT NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS listings_run ON listings (run_id);
+`
+
+// defaultHistoryDB is where confirmations are recorded unless -db says otherwise
+const defaultHistoryDB = "confirmations.db"
+
+// Confirmation sources recorded with each run
+const (
+	SourceCLI      = "cli"
+	SourceBatch    = "batch"
+	SourceServer   = "server"
+	SourceSchedule = "schedule"
+)
+
+// ConfirmationRun は履歴に記録された1回分の物件確認
+type ConfirmationRun struct {
+	ID             int64             `json:"id"`
+	PropertyName   string            `json:"property_name"`
+	RoomNumber     string            `json:"room_number,omitempty"`
+	Source         string            `json:"source"`
+	Status         string            `json:"status"`
+	ErrorCode      string            `json:"error_code,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	ResultCount    int               `json:"result_count"`
+	PageURL        string            `json:"page_url,omitempty"`
+	DOMPath        string            `json:"dom_path,omitempty"`
+	ScreenshotPath string            `json:"screenshot_path,omitempty"`
+	Incomplete     bool              `json:"incomplete,omitempty"` // not every result page was read
+	ConfirmedAt    time.Time         `json:"confirmed_at"`
+	Listings       []PropertyListing `json:"listings"`
+}
+
+// HistoryQuery selects runs from the history
+type HistoryQuery struct {
+	// PropertyName matches names containing it; empty matches all
+	PropertyName string
+
+	// RoomNumber keeps only this room's listings (normalized like batch input)
+	RoomNumber string
+
+	Since time.Time
+	Limit int
+}
+
+// HistoryStore は確認履歴を保存するSQLiteデータベース
+type HistoryStore struct {
+	db *sql.DB
+}
+
+// OpenHistoryStore opens (and if needed creates) the history database at path
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// SQLite allows one writer; batch and server workers share this connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history tables in %s: %w", path, err)
+	}
+	if err := migrateHistory(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade history tables in %s: %w", path, err)
+	}
+	return &HistoryStore{db: db}, nil
+}
+
+// migrateHistory adds columns introduced after a database was created
+func migrateHistory(db *sql.DB) error {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('runs') WHERE name = 'incomplete'`).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE runs ADD COLUMN incomplete INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// Close closes the database
+func (h *HistoryStore) Close() error {
+	return h.db.Close()
+}
+
+// Record stores one confirmation result with its listings and returns the run ID
+func (h *HistoryStore) Record(res BatchResult, source, screenshotPath string) (int64, error) {
+	run := ConfirmationRun{
+		PropertyName:   res.PropertyName,
+		RoomNumber:     res.RoomNumber,
+		Source:         source,
+		Status:         res.Status,
+		ErrorCode:      res.ErrorCode,
+		Error:          res.Error,
+		ScreenshotPath: screenshotPath,
+		ConfirmedAt:    res.ConfirmedAt,
+	}
+	if res.Result != nil {
+		run.ResultCount = res.Result.ResultCount
+		run.PageURL = res.Result.Diagnostics.PageURL
+		run.DOMPath = res.Result.Diagnostics.DOMSavedTo
+		run.Incomplete = res.Result.Incomplete
+		run.Listings = res.Result.Listings
+	}
+	if run.ConfirmedAt.IsZero() {
+		run.ConfirmedAt = time.Now()
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record confirmation: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := tx.Exec(`
+		INSERT INTO runs (property_name, room_number, source, status, error_code, error,
+			result_count, page_url, dom_path, screenshot_path, incomplete, confirmed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.PropertyName, run.RoomNumber, run.Source, run.Status, run.ErrorCode, run.Error,
+		run.ResultCount, run.PageURL, run.DOMPath, run.ScreenshotPath, run.Incomplete, formatHistoryTime(run.ConfirmedAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record confirmation: %w", err)
+	}
+	runID, err := r.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record confirmation: %w", err)
+	}
+
+	for _, l := range run.Listings {
+		raw, err := json.Marshal(l.Raw)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode listing: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO listings (run_id, name, room_number, address, url, rent, management_fee,
+				deposit, key_money, area_sqm, floor, layout, available_date, status, management_company, raw)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, l.Name, l.RoomNumber, l.Address, l.URL, l.Rent, l.ManagementFee,
+			l.Deposit, l.KeyMoney, l.AreaSqm, l.Floor, l.Layout, l.AvailableDate, l.Status, l.ManagementCompany, string(raw),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record listing: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to record confirmation: %w", err)
+	}
+	return runID, nil
+}
+
+// Runs returns the matching runs, oldest first, with their listings
+func (h *HistoryStore) Runs(q HistoryQuery) ([]ConfirmationRun, error) {
+	query := `
+		SELECT id, property_name, room_number, source, status, error_code, error,
+			result_count, page_url, dom_path, screenshot_path, incomplete, confirmed_at
+		FROM runs WHERE 1 = 1`
+	var args []any
+	if q.PropertyName != "" {
+		query += ` AND property_name LIKE ?`
+		args = append(args, "%"+q.PropertyName+"%")
+	}
+	if !q.Since.IsZero() {
+		query += ` AND confirmed_at >= ?`
+		args = append(args, formatHistoryTime(q.Since))
+	}
+	query += ` ORDER BY confirmed_at DESC, id DESC`
+	if q.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, q.Limit)
+	}
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first reads naturally as a timeline
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+
+	for i := range runs {
+		listings, err := h.listings(runs[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if q.RoomNumber != "" {
+			listings = filterListingsByRoom(listings, q.RoomNumber)
+		}
+		runs[i].Listings = listings
+	}
+	return runs, nil
+}
+
+// LatestRuns returns up to n complete runs of exactly this property and room,
+// newest first. Failed runs have no listings, and incomplete or ambiguous runs
+// hold a partial or mixed set of rooms, so none of them is a usable snapshot.
+func (h *HistoryStore) LatestRuns(propertyName, roomNumber string, n int) ([]ConfirmationRun, error) {
+	rows, err := h.db.Query(`
+		SELECT id, property_name, room_number, source, status, error_code, error,
+			result_count, page_url, dom_path, screenshot_path, incomplete, confirmed_at
+		FROM runs
+		WHERE property_name = ? AND room_number = ? AND status NOT IN (?, ?) AND incomplete = 0
+		ORDER BY confirmed_at DESC, id DESC LIMIT ?`,
+		propertyName, roomNumber, BatchStatusError, BatchStatusAmbiguousMatch, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		if runs[i].Listings, err = h.listings(runs[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return runs, nil
+}
+
+// LatestRun returns the newest complete run of this property and room, or nil
+func (h *HistoryStore) LatestRun(propertyName, roomNumber string) (*ConfirmationRun, error) {
+	runs, err := h.LatestRuns(propertyName, roomNumber, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// scanRuns reads run rows and closes them
+func scanRuns(rows *sql.Rows) ([]ConfirmationRun, error) {
+	defer rows.Close()
+
+	var runs []ConfirmationRun
+	for rows.Next() {
+		var run ConfirmationRun
+		var confirmedAt string
+		err := rows.Scan(&run.ID, &run.PropertyName, &run.RoomNumber, &run.Source, &run.Status, &run.ErrorCode, &run.Error,
+			&run.ResultCount, &run.PageURL, &run.DOMPath, &run.ScreenshotPath, &run.Incomplete, &confirmedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		run.ConfirmedAt, _ = time.Parse(time.RFC3339Nano, confirmedAt)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return runs, nil
+}
+
+// listings loads the listings recorded for a run
+func (h *HistoryStore) listings(runID int64) ([]PropertyListing, error) {
+	rows, err := h.db.Query(`
+		SELECT name, room_number, address, url, rent, management_fee, deposit, key_money,
+			area_sqm, floor, layout, available_date, status, management_company, raw
+		FROM listings WHERE run_id = ? ORDER BY id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listings: %w", err)
+	}
+	defer rows.Close()
+
+	var listings []PropertyListing
+	for rows.Next() {
+		var l PropertyListing
+		var raw string
+		err := rows.Scan(&l.Name, &l.RoomNumber, &l.Address, &l.URL, &l.Rent, &l.ManagementFee, &l.Deposit, &l.KeyMoney,
+			&l.AreaSqm, &l.Floor, &l.Layout, &l.AvailableDate, &l.Status, &l.ManagementCompany, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read listing: %w", err)
+		}
+		json.Unmarshal([]byte(raw), &l.Raw)
+		listings = append(listings, l)
+	}
+	return listings, rows.Err()
+}
+
+// RecruitingEnd is when a room was last seen 募集中 and first seen otherwise
+type RecruitingEnd struct {
+	LastRecruiting *ConfirmationRun `json:"last_recruiting,omitempty"`
+	EndedAt        *ConfirmationRun `json:"ended_at,omitempty"`
+	EndStatus      string           `json:"end_status,omitempty"`
+}
+
+// FindRecruitingEnd scans runs (oldest first) for the latest point where
+// room went from 募集中 to another status or disappeared from the results.
+// An empty room follows the room each run was confirmed with. Failed,
+// incomplete and ambiguous runs are skipped because they say nothing reliable
+// about the room.
+func FindRecruitingEnd(runs []ConfirmationRun, room string) RecruitingEnd {
+	var end RecruitingEnd
+	for i := range runs {
+		run := &runs[i]
+		if run.Status == BatchStatusError || run.Status == BatchStatusAmbiguousMatch || run.Incomplete {
+			continue
+		}
+		status := listingNotListed
+		if l := filterListingsByRoom(run.Listings, firstNonEmpty(room, run.RoomNumber)); len(l) > 0 {
+			status = l[0].Status
+		}
+		if status == "募集中" {
+			end = RecruitingEnd{LastRecruiting: run}
+		} else if end.LastRecruiting != nil && end.EndedAt == nil {
+			end.EndedAt = run
+			end.EndStatus = status
+		}
+	}
+	return end
+}
+
+// formatHistoryTime stores times as sortable UTC RFC 3339 strings
+func formatHistoryTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")
+}
+
+// recordHistory stores res and its screenshot when a history store is
+// configured, first attaching the changes since the previous run of the same
+// property to res. Failures are logged.
+func recordHistory(store *HistoryStore, res *BatchResult, source string) {
+	if store == nil {
+		return
+	}
+	diff, err := previousSnapshot(store, *res)
+	if err != nil {
+		log.Printf("Warning: Failed to compare with the previous run: %v\n", err)
+	}
+	res.Diff = diff
+
+	if _, err := store.Record(*res, source, res.Screenshot); err != nil {
+		log.Printf("Warning: Failed to record history: %v\n", err)
+	}
+}
+
+// runHistory implements the history subcommand
+func runHistory(args []string) {
+	fs := newFlagSet("history", "[flags]", "Show recorded confirmations and when a room stopped recruiting.")
+	dbPath := fs.String("db", defaultHistoryDB, "History database file")
+	propertyName := fs.String("property", "", "Show runs for property names containing this text")
+	room := fs.String("room", "", "Show only this room's listings (e.g. 302)")
+	since := fs.String("since", "", "Show runs confirmed on or after this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 50, "Maximum number of runs (most recent)")
+	asJSON := fs.Bool("json", false, "Print runs as JSON")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*dbPath); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("History database %s not found - confirmations are recorded there unless -db is empty", *dbPath)
+	}
+	store, err := OpenHistoryStore(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	q := HistoryQuery{PropertyName: *propertyName, RoomNumber: *room, Limit: *limit}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			log.Fatalf("Invalid -since %q: want YYYY-MM-DD", *since)
+		}
+		q.Since = t
+	}
+
+	runs, err := store.Runs(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		jsonData, _ := json.MarshalIndent(runs, "", "  ")
+		fmt.Println(string(jsonData))
+		return
+	}
+	printHistory(runs, *room)
+}
+
+// printHistory prints one line per listing of each run, and when room is set
+// when that room stopped recruiting
+func printHistory(runs []ConfirmationRun, room string) {
+	if len(runs) == 0 {
+		fmt.Println("No confirmations recorded")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "確認日時\t物件名\t部屋\t結果\t募集状況\t賃料\t入居時期")
+	for _, run := range runs {
+		at := run.ConfirmedAt.Local().Format("2006-01-02 15:04")
+		switch {
+		case run.Status == BatchStatusError:
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s (%s)\t\t\t\n", at, run.PropertyName, run.RoomNumber, run.Status, run.ErrorCode)
+		case len(run.Listings) == 0:
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t掲載なし\t\t\n", at, run.PropertyName, run.RoomNumber, run.Status)
+		}
+		for _, l := range run.Listings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d円\t%s\n", at, firstNonEmpty(l.Name, run.PropertyName), l.RoomNumber, run.Status, l.Status, l.Rent, l.AvailableDate)
+		}
+	}
+	w.Flush()
+
+	if room != "" {
+		end := FindRecruitingEnd(runs, room)
+		switch {
+		case end.LastRecruiting == nil:
+			fmt.Println("\nこの部屋が募集中だった記録はありません")
+		case end.EndedAt == nil:
+			fmt.Printf("\n最後の確認（%s）まで募集中です\n", end.LastRecruiting.ConfirmedAt.Local().Format("2006-01-02 15:04"))
+		default:
+			fmt.Printf("\n%s の確認で募集中でなくなりました（%s）。最後に募集中だった確認: %s\n",
+				end.EndedAt.ConfirmedAt.Local().Format("2006-01-02 15:04"), end.EndStatus,
+				end.LastRecruiting.ConfirmedAt.Local().Format("2006-01-02 15:04"))
+		}
+	}
+}
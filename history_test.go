@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// historyResult builds a found result with one listing per room/status pair
+func historyResult(name string, at time.Time, rooms ...[2]string) BatchResult {
+	res := BatchResult{BatchItem: BatchItem{PropertyName: name}, ConfirmedAt: at}
+	result := &SearchResult{Status: SearchStatusFound}
+	for _, r := range rooms {
+		result.Listings = append(result.Listings, PropertyListing{
+			Name:       name,
+			RoomNumber: r[0],
+			Status:     r[1],
+			Rent:       77000,
+			Raw:        map[string]string{"rent": "7.7万円"},
+		})
+	}
+	res.setResult(result)
+	return res
+}
+
+func TestHistoryStoreRecordAndQuery(t *testing.T) {
+	store, err := OpenHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	failed := BatchResult{BatchItem: BatchItem{PropertyName: "クレール住吉"}, ConfirmedAt: day.Add(12 * time.Hour)}
+	failed.setError(ErrSessionExpired)
+
+	records := []BatchResult{
+		historyResult("クレール住吉", day, [2]string{"302", "募集中"}, [2]string{"101", "募集中"}),
+		failed,
+		historyResult("クレール住吉", day.AddDate(0, 0, 1), [2]string{"302", "申込あり"}, [2]string{"101", "募集中"}),
+		historyResult("サンプル物件", day.AddDate(0, 0, 1), [2]string{"201", "募集中"}),
+		historyResult("クレール住吉", day.AddDate(0, 0, 2), [2]string{"101", "募集中"}),
+	}
+	for _, res := range records {
+		if _, err := store.Record(res, SourceBatch, ""); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	runs, err := store.Runs(HistoryQuery{PropertyName: "クレール"})
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("got %d runs, want 4", len(runs))
+	}
+	if !runs[0].ConfirmedAt.Equal(day) || runs[1].ErrorCode != "session_expired" {
+		t.Errorf("runs not oldest first or error code lost: %+v", runs[:2])
+	}
+	if l := runs[0].Listings; len(l) != 2 || l[0].Rent != 77000 || l[0].Raw["rent"] != "7.7万円" {
+		t.Errorf("listings = %+v", l)
+	}
+
+	runs, err = store.Runs(HistoryQuery{PropertyName: "クレール", RoomNumber: "３０２号室"})
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	end := FindRecruitingEnd(runs, "302")
+	if end.LastRecruiting == nil || !end.LastRecruiting.ConfirmedAt.Equal(day) {
+		t.Fatalf("LastRecruiting = %+v", end.LastRecruiting)
+	}
+	if end.EndedAt == nil || !end.EndedAt.ConfirmedAt.Equal(day.AddDate(0, 0, 1)) || end.EndStatus != "申込あり" {
+		t.Errorf("EndedAt = %+v, EndStatus = %q", end.EndedAt, end.EndStatus)
+	}
+
+	limited, err := store.Runs(HistoryQuery{Since: day.AddDate(0, 0, 1), Limit: 1})
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(limited) != 1 || !limited[0].ConfirmedAt.Equal(day.AddDate(0, 0, 2)) {
+		t.Errorf("Limit should keep the most recent run: %+v", limited)
+	}
+}
+
+func TestFindRecruitingEndStillRecruiting(t *testing.T) {
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	runs := []ConfirmationRun{
+		{Status: BatchStatusNoResults, ConfirmedAt: day},
+		{Status: BatchStatusFound, RoomNumber: "302", ConfirmedAt: day.AddDate(0, 0, 1), Listings: []PropertyListing{{RoomNumber: "302", Status: "募集中"}}},
+	}
+	end := FindRecruitingEnd(runs, "")
+	if end.LastRecruiting == nil || end.EndedAt != nil {
+		t.Errorf("end = %+v, want still recruiting", end)
+	}
+}
+
+func TestFindRecruitingEndSkipsUnreliableRuns(t *testing.T) {
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	recruiting := []PropertyListing{{RoomNumber: "101", Status: "申込あり"}, {RoomNumber: "302号室", Status: "募集中"}}
+	runs := []ConfirmationRun{
+		{Status: BatchStatusFound, RoomNumber: "302", ConfirmedAt: day, Listings: recruiting},
+		// Another building's room comes first
+		{Status: BatchStatusAmbiguousMatch, RoomNumber: "302", ConfirmedAt: day.AddDate(0, 0, 1),
+			Listings: []PropertyListing{{Name: "クレール住吉公園", RoomNumber: "302", Status: "成約済み"}}},
+		// Room 302 was on a page that failed to load
+		{Status: BatchStatusFound, RoomNumber: "302", ConfirmedAt: day.AddDate(0, 0, 2), Incomplete: true,
+			Listings: []PropertyListing{{RoomNumber: "101", Status: "申込あり"}}},
+		{Status: BatchStatusFound, RoomNumber: "302", ConfirmedAt: day.AddDate(0, 0, 3), Listings: recruiting},
+	}
+	end := FindRecruitingEnd(runs, "")
+	if end.LastRecruiting == nil || !end.LastRecruiting.ConfirmedAt.Equal(day.AddDate(0, 0, 3)) || end.EndedAt != nil {
+		t.Errorf("end = %+v, want still recruiting at the last run", end)
+	}
+
+	runs = append(runs, ConfirmationRun{Status: BatchStatusFound, RoomNumber: "302", ConfirmedAt: day.AddDate(0, 0, 4),
+		Listings: []PropertyListing{{RoomNumber: "101", Status: "募集中"}}})
+	end = FindRecruitingEnd(runs, "")
+	if end.EndedAt == nil || !end.EndedAt.ConfirmedAt.Equal(day.AddDate(0, 0, 4)) || end.EndStatus != listingNotListed {
+		t.Errorf("end = %+v, want ended as 掲載なし", end)
+	}
+}
+
+func TestOpenHistoryStoreAddsIncompleteColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// runs as created before incomplete was recorded
+	_, err = db.Exec(`CREATE TABLE runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, property_name TEXT NOT NULL,
+		room_number TEXT NOT NULL DEFAULT '', source TEXT NOT NULL DEFAULT '', status TEXT NOT NULL,
+		error_code TEXT NOT NULL DEFAULT '', error TEXT NOT NULL DEFAULT '', result_count INTEGER NOT NULL DEFAULT 0,
+		page_url TEXT NOT NULL DEFAULT '', dom_path TEXT NOT NULL DEFAULT '', screenshot_path TEXT NOT NULL DEFAULT '',
+		confirmed_at TEXT NOT NULL)`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenHistoryStore(path)
+	if err != nil {
+		t.Fatalf("OpenHistoryStore: %v", err)
+	}
+	defer store.Close()
+	res := historyResult("クレール住吉", time.Now(), [2]string{"302", "募集中"})
+	res.Result.Incomplete = true
+	if _, err := store.Record(res, SourceCLI, ""); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	runs, err := store.Runs(HistoryQuery{})
+	if err != nil || len(runs) != 1 || !runs[0].Incomplete {
+		t.Errorf("Runs = %+v, %v", runs, err)
+	}
+}
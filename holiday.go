@@ -0,0 +1,135 @@
+package main
+
+import "time"
+
+// japaneseHolidayName returns the name of the Japanese public holiday on the
+// date of t, or "" when it is not a holiday. It follows the Act on National
+// Holidays as amended through 2020, including 振替休日 and 国民の休日; dates
+// before 2007 are not supported.
+func japaneseHolidayName(t time.Time) string {
+	y, m, d := t.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	if name := fixedHolidayName(date); name != "" {
+		return name
+	}
+
+	// 振替休日: the first non-holiday after a holiday falling on Sunday
+	for prev := date.AddDate(0, 0, -1); fixedHolidayName(prev) != ""; prev = prev.AddDate(0, 0, -1) {
+		if prev.Weekday() == time.Sunday {
+			return "振替休日"
+		}
+	}
+
+	// 国民の休日: a weekday sandwiched between two holidays
+	if date.Weekday() != time.Sunday &&
+		fixedHolidayName(date.AddDate(0, 0, -1)) != "" && fixedHolidayName(date.AddDate(0, 0, 1)) != "" {
+		return "国民の休日"
+	}
+	return ""
+}
+
+// fixedHolidayName returns the holiday defined for date by the calendar rules
+// alone, without substitute holidays
+func fixedHolidayName(date time.Time) string {
+	y, m, d := date.Date()
+	switch special := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Format("2006-01-02"); special {
+	// The 2019 enthronement and the holidays moved for the Tokyo Olympics
+	case "2019-05-01":
+		return "天皇の即位の日"
+	case "2019-10-22":
+		return "即位礼正殿の儀の行われる日"
+	case "2020-07-23", "2021-07-22":
+		return "海の日"
+	case "2020-07-24", "2021-07-23":
+		return "スポーツの日"
+	case "2020-08-10", "2021-08-08":
+		return "山の日"
+	}
+	olympicYear := y == 2020 || y == 2021
+
+	switch m {
+	case time.January:
+		if d == 1 {
+			return "元日"
+		}
+		if d == nthMonday(y, m, 2) {
+			return "成人の日"
+		}
+	case time.February:
+		if d == 11 {
+			return "建国記念の日"
+		}
+		if d == 23 && y >= 2020 {
+			return "天皇誕生日"
+		}
+	case time.March:
+		if d == vernalEquinoxDay(y) {
+			return "春分の日"
+		}
+	case time.April:
+		if d == 29 {
+			return "昭和の日"
+		}
+	case time.May:
+		switch d {
+		case 3:
+			return "憲法記念日"
+		case 4:
+			return "みどりの日"
+		case 5:
+			return "こどもの日"
+		}
+	case time.July:
+		if d == nthMonday(y, m, 3) && !olympicYear {
+			return "海の日"
+		}
+	case time.August:
+		if d == 11 && y >= 2016 && !olympicYear {
+			return "山の日"
+		}
+	case time.September:
+		if d == nthMonday(y, m, 3) {
+			return "敬老の日"
+		}
+		if d == autumnalEquinoxDay(y) {
+			return "秋分の日"
+		}
+	case time.October:
+		if d == nthMonday(y, m, 2) && !olympicYear {
+			if y < 2020 {
+				return "体育の日"
+			}
+			return "スポーツの日"
+		}
+	case time.November:
+		switch d {
+		case 3:
+			return "文化の日"
+		case 23:
+			return "勤労感謝の日"
+		}
+	case time.December:
+		if d == 23 && y <= 2018 {
+			return "天皇誕生日"
+		}
+	}
+	return ""
+}
+
+// nthMonday returns the day of the month of the n-th Monday
+func nthMonday(year int, month time.Month, n int) int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	offset := (int(time.Monday) - int(first) + 7) % 7
+	return 1 + offset + 7*(n-1)
+}
+
+// vernalEquinoxDay returns the March day of 春分の日 (valid 1980-2099)
+func vernalEquinoxDay(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// autumnalEquinoxDay returns the September day of 秋分の日 (valid 1980-2099)
+func autumnalEquinoxDay(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
@@ -2,20 +2,18 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/chromedp/chromedp"
 )
 
-const (
-	loginURL = "https://itandi-accounts.com/"
-)
-
 var (
 	loginEmail    = os.Getenv("ITANDI_EMAIL")
 	loginPassword = os.Getenv("ITANDI_PASSWORD")
@@ -23,30 +21,83 @@ var (
 
 // ITANDIScraper はITANDI BBのスクレーパー
 type ITANDIScraper struct {
-	ctx    context.Context
-	cancel context.CancelFunc
+	ctx       context.Context
+	cancel    context.CancelFunc
+	site      SiteConfig
+	session   SessionOptions
+	selectors *SelectorStore
+	waits     WaitTimeouts
+	retry     RetryPolicy
+	network   *networkTracker
+
+	// detailPages opens each listing's 詳細 page; see UseDetailPages
+	detailPages bool
+
+	// target is the property of the last Search, matched against the result cards
+	target MatchTarget
 }
 
-// NewITANDIScraper creates a new scraper instance
+// NewITANDIScraper creates a new scraper instance with a fresh browser profile
 func NewITANDIScraper(headless bool) (*ITANDIScraper, error) {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath("/Applications/Chromium.app/Contents/MacOS/Chromium"),
-		chromedp.Flag("headless", headless),
-	)
+	cfg := DefaultBrowserConfig()
+	cfg.Headless = headless
+	return NewITANDIScraperWithConfig(cfg, DefaultSiteConfig(), SessionOptions{})
+}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, cancel2 := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+// NewITANDIScraperWithConfig creates a scraper from a browser config and site
+// endpoints that can reuse a saved login session
+func NewITANDIScraperWithConfig(cfg BrowserConfig, site SiteConfig, session SessionOptions) (*ITANDIScraper, error) {
+	if session.ProfileDir != "" {
+		cfg.UserDataDir = session.ProfileDir
+	}
 
-	// Create a combined cancel function
-	combinedCancel := func() {
-		cancel2()
-		cancel()
+	ctx, cancel, err := newBrowserContext(cfg)
+	if err != nil {
+		return nil, err
 	}
 
+	return newITANDIScraper(ctx, cancel, site, session), nil
+}
+
+// newITANDIScraperTab opens a scraper in a new tab of a running browser.
+// Closing it closes only the tab.
+func newITANDIScraperTab(browserCtx context.Context, site SiteConfig, session SessionOptions) *ITANDIScraper {
+	ctx, cancel := chromedp.NewContext(browserCtx)
+	return newITANDIScraper(ctx, cancel, site, session)
+}
+
+// newITANDIScraper wraps a tab context with the default selectors, waits and retry policy
+func newITANDIScraper(ctx context.Context, cancel context.CancelFunc, site SiteConfig, session SessionOptions) *ITANDIScraper {
 	return &ITANDIScraper{
-		ctx:    ctx,
-		cancel: combinedCancel,
-	}, nil
+		ctx:       ctx,
+		cancel:    cancel,
+		site:      site,
+		session:   session,
+		selectors: NewSelectorStore(),
+		waits:     DefaultWaitTimeouts(),
+		retry:     DefaultRetryPolicy(),
+		network:   trackNetwork(ctx),
+	}
+}
+
+// UseSelectors replaces the built-in selectors with a loaded (and possibly watched) store
+func (s *ITANDIScraper) UseSelectors(store *SelectorStore) {
+	s.selectors = store
+}
+
+// selectorNotFound builds a SelectorNotFoundError for a field of the selector config
+func (s *ITANDIScraper) selectorNotFound(field string) error {
+	return &SelectorNotFoundError{Field: field, Tried: len(s.selectors.Get(field))}
+}
+
+// UseWaitTimeouts replaces the default per-step wait limits
+func (s *ITANDIScraper) UseWaitTimeouts(waits WaitTimeouts) {
+	s.waits = waits
+}
+
+// waitPageSettled waits for the current document to load and the network to go quiet
+func (s *ITANDIScraper) waitPageSettled(step string) error {
+	return waitFor(s.ctx, step, s.waits.Page, waitDOMReady(), waitNetworkIdle(s.network))
 }
 
 // Close cleans up resources
@@ -54,17 +105,22 @@ func (s *ITANDIScraper) Close() {
 	s.cancel()
 }
 
-// NavigateToLogin navigates to the login page
+// NavigateToLogin navigates to the login page, retrying per the retry policy
 func (s *ITANDIScraper) NavigateToLogin() error {
+	return s.retry.Do(s.ctx, "navigate to login", s.navigateToLogin, nil)
+}
+
+// navigateToLogin makes a single attempt to open the login page
+func (s *ITANDIScraper) navigateToLogin() error {
 	log.Println("Navigating to ITANDI login page...")
 
 	err := chromedp.Run(s.ctx,
-		chromedp.Navigate(loginURL),
+		chromedp.Navigate(s.site.LoginURL()),
 		chromedp.WaitReady("body"),
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to navigate to login page: %w", err)
+		return fmt.Errorf("%w: login page: %w", ErrNavigationFailed, err)
 	}
 
 	log.Println("Successfully navigated to login page")
@@ -92,26 +148,51 @@ func (s *ITANDIScraper) TakeScreenshot(filename string) error {
 	return nil
 }
 
-// Login performs flexible login to ITANDI BB
+// screenshotDir holds the per-run search result screenshots
+const screenshotDir = "screenshots"
+
+// screenshotSeq keeps screenshot names unique across parallel workers
+var screenshotSeq atomic.Int64
+
+// captureRunScreenshot saves the current page under a name unique to this
+// run and returns its absolute path, or "" when it could not be saved
+func (s *ITANDIScraper) captureRunScreenshot() string {
+	if err := os.MkdirAll(screenshotDir, 0755); err != nil {
+		log.Println("Warning: Failed to create screenshot directory:", err)
+		return ""
+	}
+	name := fmt.Sprintf("search_results_%s_%d.png", time.Now().Format("20060102_150405"), screenshotSeq.Add(1))
+	path := filepath.Join(screenshotDir, name)
+	if err := s.TakeScreenshot(path); err != nil {
+		log.Println("Warning: Failed to take screenshot:", err)
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// Login performs flexible login to ITANDI BB, reopening the login page
+// between attempts per the retry policy
 func (s *ITANDIScraper) Login() error {
+	return s.retry.Do(s.ctx, "login", s.login, func(error) error {
+		return s.navigateToLogin()
+	})
+}
+
+// login makes a single login attempt on the current page
+func (s *ITANDIScraper) login() error {
 	log.Println("Starting adaptive login process...")
 
-	// First, determine what type of login interface is available
-	time.Sleep(2 * time.Second)
+	// First, let the page settle so we can determine what type of login interface is available
+	if err := s.waitPageSettled("login page"); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	// Check for email/password inputs
-	var hasEmailInput, hasPasswordInput bool
-	chromedp.Run(s.ctx,
-		chromedp.EvaluateAsDevTools(`
-			document.querySelector('input[type="email"], input[name="email"], input[id*="email"], input[placeholder*="email"], input[placeholder*="メール"]') !== null
-		`, &hasEmailInput),
-	)
-
-	chromedp.Run(s.ctx,
-		chromedp.EvaluateAsDevTools(`
-			document.querySelector('input[type="password"], input[name="password"], input[id*="password"], input[placeholder*="password"], input[placeholder*="パスワード"]') !== null
-		`, &hasPasswordInput),
-	)
+	hasEmailInput := locatorExists(s.ctx, s.selectors.Get("login.email"))
+	hasPasswordInput := locatorExists(s.ctx, s.selectors.Get("login.password"))
 
 	if hasEmailInput && hasPasswordInput {
 		log.Println("Found email/password login form - attempting email login")
@@ -119,60 +200,38 @@ func (s *ITANDIScraper) Login() error {
 	}
 
 	// Check for company selection (phone verification system)
-	var hasCompanySelect bool
-	chromedp.Run(s.ctx,
-		chromedp.EvaluateAsDevTools(`
-			document.querySelector('#company_id_select, select[name="company_id"]') !== null
-		`, &hasCompanySelect),
-	)
+	hasCompanySelect := locatorExists(s.ctx, s.selectors.Get("login.company_select"))
 
 	if hasCompanySelect {
 		log.Println("Found company selection form - this appears to be phone verification system")
 		log.Println("Please use -updated flag for phone verification system")
-		return fmt.Errorf("phone verification system detected - use -updated flag")
+		return fmt.Errorf("%w - use -updated flag", ErrPhoneVerificationRequired)
 	}
 
 	// Look for any clickable login elements
 	log.Println("Looking for login buttons or links...")
 
-	loginElements := []string{
-		`a[href*="login"]`,
-		`a[href*="sign_in"]`,
-		`button:contains("ログイン")`,
-		`button:contains("Login")`,
-		`button:contains("Sign In")`,
-		`.login-btn`,
-		`.signin-btn`,
-	}
+	loginElements := s.selectors.Get("login.link")
 
 	for _, selector := range loginElements {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Clicked login element: %s\n", selector)
-			time.Sleep(3 * time.Second)
 
-			// Check again for email/password inputs after clicking
-			chromedp.Run(s.ctx,
-				chromedp.EvaluateAsDevTools(`
-					document.querySelector('input[type="email"], input[name="email"], input[id*="email"]') !== null
-				`, &hasEmailInput),
-			)
-			chromedp.Run(s.ctx,
-				chromedp.EvaluateAsDevTools(`
-					document.querySelector('input[type="password"], input[name="password"], input[id*="password"]') !== null
-				`, &hasPasswordInput),
+			// Wait for email/password inputs to appear after clicking
+			err = waitFor(s.ctx, "login form", s.waits.Login,
+				waitLocator("login.email", s.selectors.Get("login.email")),
+				waitLocator("login.password", s.selectors.Get("login.password")),
 			)
-
-			if hasEmailInput && hasPasswordInput {
+			if err == nil {
 				log.Println("Email/password form appeared after clicking - attempting login")
 				return s.performEmailPasswordLogin()
 			}
+			log.Printf("Email/password form did not appear: %v\n", err)
 		}
 	}
 
-	return fmt.Errorf("no compatible login method found - ITANDI BB may require phone verification")
+	return fmt.Errorf("no compatible login method found - ITANDI BB may require phone verification: %w", s.selectorNotFound("login.email"))
 }
 
 // performEmailPasswordLogin handles the actual email/password login
@@ -185,19 +244,11 @@ func (s *ITANDIScraper) performEmailPasswordLogin() error {
 	}
 
 	// Find and fill email
-	emailSelectors := []string{
-		`input[type="email"]`,
-		`input[name="email"]`,
-		`input[id*="email"]`,
-		`input[placeholder*="email"]`,
-		`input[placeholder*="メール"]`,
-	}
+	emailSelectors := s.selectors.Get("login.email")
 
 	var emailFilled bool
 	for _, selector := range emailSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.SendKeys(selector, loginEmail, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := sendKeysLocator(s.ctx, selector, loginEmail)
 		if err == nil {
 			log.Printf("Email entered using: %s\n", selector)
 			emailFilled = true
@@ -206,23 +257,15 @@ func (s *ITANDIScraper) performEmailPasswordLogin() error {
 	}
 
 	if !emailFilled {
-		return fmt.Errorf("could not fill email field")
+		return fmt.Errorf("could not fill email field: %w", s.selectorNotFound("login.email"))
 	}
 
 	// Find and fill password
-	passwordSelectors := []string{
-		`input[type="password"]`,
-		`input[name="password"]`,
-		`input[id*="password"]`,
-		`input[placeholder*="password"]`,
-		`input[placeholder*="パスワード"]`,
-	}
+	passwordSelectors := s.selectors.Get("login.password")
 
 	var passwordFilled bool
 	for _, selector := range passwordSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.SendKeys(selector, loginPassword, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := sendKeysLocator(s.ctx, selector, loginPassword)
 		if err == nil {
 			log.Printf("Password entered using: %s\n", selector)
 			passwordFilled = true
@@ -231,27 +274,20 @@ func (s *ITANDIScraper) performEmailPasswordLogin() error {
 	}
 
 	if !passwordFilled {
-		return fmt.Errorf("could not fill password field")
+		return fmt.Errorf("could not fill password field: %w", s.selectorNotFound("login.password"))
 	}
 
-	time.Sleep(1 * time.Second)
+	loginPageURL, err := s.GetPageURL()
+	if err != nil {
+		return fmt.Errorf("failed to read login page URL: %w", err)
+	}
 
 	// Submit form
-	submitSelectors := []string{
-		`button[type="submit"]`,
-		`input[type="submit"]`,
-		`button:contains("ログイン")`,
-		`button:contains("Login")`,
-		`button:contains("Sign In")`,
-		`.login-btn`,
-		`.submit-btn`,
-	}
+	submitSelectors := s.selectors.Get("login.submit")
 
 	var submitted bool
 	for _, selector := range submitSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Submitted using: %s\n", selector)
 			submitted = true
@@ -261,7 +297,7 @@ func (s *ITANDIScraper) performEmailPasswordLogin() error {
 
 	if !submitted {
 		// Try Enter key
-		err := chromedp.Run(s.ctx,
+		err = chromedp.Run(s.ctx,
 			chromedp.KeyEvent("\r"),
 		)
 		if err == nil {
@@ -271,64 +307,108 @@ func (s *ITANDIScraper) performEmailPasswordLogin() error {
 	}
 
 	if !submitted {
-		return fmt.Errorf("could not submit login form")
+		return fmt.Errorf("could not submit login form: %w", s.selectorNotFound("login.submit"))
+	}
+
+	// Wait for the redirect away from the login page
+	err = waitFor(s.ctx, "login", s.waits.Login, waitURLChange(loginPageURL), waitDOMReady())
+	if err != nil {
+		// Still showing the password form means the credentials were refused
+		if errors.Is(err, ErrTimeout) && locatorExists(s.ctx, s.selectors.Get("login.password")) {
+			return fmt.Errorf("%w: still on the login page after submitting", ErrLoginRejected)
+		}
+		return fmt.Errorf("login did not complete: %w", err)
+	}
+	if err := s.waitPageSettled("after login"); err != nil {
+		log.Printf("Warning: %v\n", err)
 	}
 
-	// Wait for response
-	time.Sleep(5 * time.Second)
+	// A refused login re-renders the form, usually at another login URL
+	// (e.g. the POST target), so a changed URL alone is not success
+	if url, err := s.GetPageURL(); err == nil && s.site.IsLoginPage(url) {
+		return fmt.Errorf("%w: returned to the login page after submitting", ErrLoginRejected)
+	}
+	if locatorExists(s.ctx, s.selectors.Get("login.password")) {
+		return fmt.Errorf("%w: password form still shown after submitting", ErrLoginRejected)
+	}
 
 	log.Println("Email/password login completed")
 	return nil
 }
 
-// SearchProperty searches for a property by name following ITANDI BB's actual flow
+// SearchProperty searches for a property by name following ITANDI BB's actual
+// flow. Failed attempts are retried after a reload, or after logging in again
+// when the session expired.
 func (s *ITANDIScraper) SearchProperty(propertyName string) error {
-	log.Printf("Searching for property: %s\n", propertyName)
+	return s.Search(SearchCriteria{PropertyName: propertyName})
+}
+
+// Search runs the list search with the criteria set on the search form,
+// retrying like SearchProperty
+func (s *ITANDIScraper) Search(criteria SearchCriteria) error {
+	if err := criteria.Validate(); err != nil {
+		return err
+	}
+	s.target = MatchTarget{
+		Name:              criteria.PropertyName,
+		Address:           criteria.Address,
+		ManagementCompany: criteria.ManagementCompany,
+	}
+	return s.retry.Do(s.ctx, "search", func() error {
+		return s.search(criteria)
+	}, s.recoverStep)
+}
+
+// search makes a single search attempt
+func (s *ITANDIScraper) search(criteria SearchCriteria) error {
+	log.Printf("Searching for property: %s\n", criteria)
 
 	var err error
 
 	// Step 1: Wait for page to stabilize and ensure we're on the correct page
-	time.Sleep(2 * time.Second)
+	if err := s.waitPageSettled("search"); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	// Check if we're on the top page
-	url, _ := s.GetPageURL()
+	url, err := s.GetPageURL()
+	if err != nil {
+		return fmt.Errorf("failed to read page URL: %w", err)
+	}
 	log.Printf("Current URL: %s\n", url)
 
 	// If we're not on the top page, navigate to it
-	if !strings.Contains(url, "/top") {
+	if !s.site.IsTopPage(url) {
 		log.Println("Navigating to ITANDI BB top page...")
 		err := chromedp.Run(s.ctx,
-			chromedp.Navigate("https://itandibb.com/top"),
+			chromedp.Navigate(s.site.TopURL()),
 			chromedp.WaitReady("body"),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to navigate to top page: %w", err)
+			return fmt.Errorf("%w: top page: %w", ErrNavigationFailed, err)
 		}
-		time.Sleep(2 * time.Second)
+		url = s.site.TopURL()
+	}
+
+	// ITANDI BB sends expired sessions back to the login form
+	if locatorExists(s.ctx, s.selectors.Get("login.password")) {
+		return fmt.Errorf("login form shown instead of ITANDI BB: %w", ErrSessionExpired)
 	}
 
 	// Step 2: Find and click the rental module's list search button
 	log.Println("Looking for rental module list search button...")
 
 	// Try various possible selectors for the list search button
-	listSearchSelectors := []string{
-		`a:contains("リスト検索")`,
-		`button:contains("リスト検索")`,
-		`.rental-module a:contains("リスト検索")`,
-		`[class*="rental"] a:contains("検索")`,
-		`a[href*="list"], a[href*="search"]`,
-		`div:contains("賃貸") a:contains("検索")`,
-		// More specific selectors
-		`a[href*="/properties"], a[href*="/search"]`,
-		`.module-rental a`,
-		`#rental-search`,
+	listSearchSelectors := s.selectors.Get("search.list_button")
+
+	err = waitFor(s.ctx, "top page", s.waits.Search, waitDOMReady(), waitLocator("search.list_button", listSearchSelectors))
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
 	}
 
 	var clicked bool
 	for _, selector := range listSearchSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Clicked list search using selector: %s\n", selector)
 			clicked = true
@@ -354,13 +434,21 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 			`, &clicked),
 		)
 		if err != nil || !clicked {
-			return fmt.Errorf("could not find list search button in rental module")
+			return fmt.Errorf("could not find list search button in rental module: %w", s.selectorNotFound("search.list_button"))
 		}
 		log.Println("Clicked list search using JavaScript")
 	}
 
 	// Wait for navigation to search page
-	time.Sleep(3 * time.Second)
+	err = waitFor(s.ctx, "list search page", s.waits.Search,
+		waitURLChange(url),
+		waitDOMReady(),
+		waitLocator("search.property_name_input", s.selectors.Get("search.property_name_input")),
+		waitNetworkIdle(s.network),
+	)
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	// Step 3: FIRST - Close modal advertisements on the list page
 	log.Println("=== Step 3-1: Closing modal advertisements on list page ===")
@@ -373,21 +461,7 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 		// Check if modal is still visible
 		var hasVisibleModal bool
 		err = chromedp.Run(s.ctx,
-			chromedp.Evaluate(`
-				(() => {
-					// Look for the specific ITANDI modal text
-					const modalElements = document.querySelectorAll('*');
-					for (let el of modalElements) {
-						if (el.textContent && el.textContent.includes('イタンジ売却査定')) {
-							const style = window.getComputedStyle(el);
-							if (style.display !== 'none' && style.visibility !== 'hidden') {
-								return true;
-							}
-						}
-					}
-					return false;
-				})()
-			`, &hasVisibleModal),
+			chromedp.Evaluate(modalVisibleJS, &hasVisibleModal),
 		)
 
 		if err == nil && !hasVisibleModal {
@@ -400,20 +474,10 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 		var closeSuccess bool
 
 		// Strategy 1: Direct selector approach
-		closeSelectors := []string{
-			`button[aria-label="close"]`,
-			`button[title="close"]`,
-			`span:contains("×")`,
-			`div:contains("×")`,
-			`[class*="close"]`,
-			`.modal-close`,
-			`[role="dialog"] button`,
-		}
+		closeSelectors := s.selectors.Get("modal.close")
 
 		for _, selector := range closeSelectors {
-			err = chromedp.Run(s.ctx,
-				chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-			)
+			err = clickLocator(s.ctx, selector)
 			if err == nil {
 				log.Printf("Closed modal using selector: %s\n", selector)
 				closeSuccess = true
@@ -498,7 +562,12 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 			log.Printf("Failed to find/click close button on attempt %d\n", attempt)
 		}
 
-		time.Sleep(2 * time.Second)
+		// Wait for the modal to go away before trying again
+		if err := waitFor(s.ctx, "modal", s.waits.Modal, waitModalGone()); err == nil {
+			log.Println("Modal successfully closed!")
+			modalClosed = true
+			break
+		}
 	}
 
 	if !modalClosed {
@@ -511,60 +580,43 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 	}
 
 	// Step 3-2: Find and fill the property name search field
-	log.Println("=== Step 3-2: Entering property name ===")
-
-	// Try various selectors for property name input
-	propertyNameSelectors := []string{
-		`input[placeholder*="物件名"]`,
-		`input[placeholder*="カナ検索"]`,
-		`input[name*="property"]`,
-		`input[name*="building"]`,
-		`input[type="text"][placeholder*="物件"]`,
-		`input[type="text"]:has(~ label:contains("物件名"))`,
-		// More generic selectors
-		`form input[type="text"]:first`,
-		`.search-form input[type="text"]`,
-		`#property_name, #building_name`,
-	}
-
-	var inputFilled bool
-	for _, selector := range propertyNameSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.SendKeys(selector, propertyName, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
-		if err == nil {
-			log.Printf("Entered property name using selector: %s\n", selector)
-			inputFilled = true
-			break
+	if criteria.PropertyName != "" {
+		log.Println("=== Step 3-2: Entering property name ===")
+
+		// Width, 第2/Ⅱ and 棟 differences are left to the result matching
+		query := searchQuery(criteria.PropertyName)
+		if query != criteria.PropertyName {
+			log.Printf("Searching for %q as %q\n", criteria.PropertyName, query)
+		}
+
+		// Try various selectors for property name input
+		propertyNameSelectors := s.selectors.Get("search.property_name_input")
+
+		var inputFilled bool
+		for _, selector := range propertyNameSelectors {
+			err := sendKeysLocator(s.ctx, selector, query)
+			if err == nil {
+				log.Printf("Entered property name using selector: %s\n", selector)
+				inputFilled = true
+				break
+			}
+		}
+
+		if !inputFilled {
+			// Label-based lookups are covered by "label=" locators in the selector config
+			if !modalClosed {
+				return fmt.Errorf("could not enter property name: %w", ErrModalBlocking)
+			}
+			return fmt.Errorf("could not find property name input field: %w", s.selectorNotFound("search.property_name_input"))
 		}
 	}
 
-	if !inputFilled {
-		// Try to find input by looking for labels
-		err = chromedp.Run(s.ctx,
-			chromedp.Evaluate(`
-				const labels = Array.from(document.querySelectorAll('label'));
-				const propertyLabel = labels.find(label => 
-					label.textContent.includes('物件名') || 
-					label.textContent.includes('カナ検索')
-				);
-				if (propertyLabel) {
-					const input = propertyLabel.parentElement.querySelector('input[type="text"]') ||
-								  document.getElementById(propertyLabel.getAttribute('for'));
-					if (input) {
-						input.value = '${propertyName}';
-						input.dispatchEvent(new Event('input', { bubbles: true }));
-						input.dispatchEvent(new Event('change', { bubbles: true }));
-						true;
-					}
-				}
-				false;
-			`, nil),
-		)
-		if err != nil {
-			return fmt.Errorf("could not find property name input field")
+	// The remaining criteria (area, rent, layout, ...) go into their own controls
+	if err := s.fillCriteria(criteria); err != nil {
+		if !modalClosed {
+			return fmt.Errorf("could not set search criteria: %w", ErrModalBlocking)
 		}
-		log.Println("Entered property name using JavaScript")
+		return err
 	}
 
 	// Take screenshot after input
@@ -572,27 +624,15 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 		log.Println("Warning: Failed to take screenshot after input:", err)
 	}
 
-	time.Sleep(1 * time.Second)
-
 	// Step 3-3: Click the search button (避开条件保存按钮)
 	log.Println("=== Step 3-3: Clicking search button ===")
 
 	// First try specific selectors for the orange search button (avoiding 条件保存)
-	specificSearchSelectors := []string{
-		`button[style*="background-color: rgb(255, 145, 65)"]`, // Orange background
-		`button[style*="background: rgb(255, 145, 65)"]`,
-		`button.MuiButton-containedPrimary:contains("検索")`, // Material-UI primary button
-		`button[class*="orange"]:contains("検索")`,
-		`button[class*="primary"]:contains("検索"):not(:contains("削除")):not(:contains("保存"))`,
-		`input[type="submit"][value="検索"][style*="background"]`,
-		`button:contains("検索"):not(:contains("削除")):not(:contains("保存")):not(:contains("条件"))`,
-	}
+	specificSearchSelectors := s.selectors.Get("search.submit_button")
 
 	var searchClicked bool
 	for _, selector := range specificSearchSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Clicked search button using specific selector: %s\n", selector)
 			searchClicked = true
@@ -725,11 +765,18 @@ func (s *ITANDIScraper) SearchProperty(propertyName string) error {
 	}
 
 	if !searchClicked {
-		return fmt.Errorf("could not submit search")
+		return fmt.Errorf("could not submit search: %w", s.selectorNotFound("search.submit_button"))
 	}
 
 	// Wait for search results
-	time.Sleep(5 * time.Second)
+	err = waitFor(s.ctx, "search results", s.waits.Results,
+		waitNetworkIdle(s.network),
+		waitDOMReady(),
+		waitResults(),
+	)
+	if err != nil {
+		return fmt.Errorf("search results did not load: %w", err)
+	}
 
 	log.Println("Property search completed")
 	return nil
@@ -750,14 +797,32 @@ func (s *ITANDIScraper) GetPropertyDOM(selector string) (string, error) {
 	return content, nil
 }
 
-// GetPropertyDetails extracts multiple DOM elements from ITANDI BB search results
-func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
+// GetPropertyDetails extracts the listings shown on the ITANDI BB search
+// results page, reloading it between attempts per the retry policy
+func (s *ITANDIScraper) GetPropertyDetails() (*SearchResult, error) {
+	var result *SearchResult
+	err := s.retry.Do(s.ctx, "extraction", func() error {
+		var err error
+		result, err = s.getPropertyDetails()
+		return err
+	}, func(error) error {
+		return s.reloadPage()
+	})
+	return result, err
+}
+
+// getPropertyDetails makes a single extraction attempt
+func (s *ITANDIScraper) getPropertyDetails() (*SearchResult, error) {
 	log.Println("Getting property details from search results...")
 
-	details := make(map[string]string)
+	result := &SearchResult{
+		Fields: make(map[string]string),
+	}
 
 	// Wait for search results to load
-	time.Sleep(3 * time.Second)
+	if err := waitFor(s.ctx, "search results", s.waits.Results, waitDOMReady(), waitResults()); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	// Close any remaining modals once before extracting results
 	log.Println("Closing any remaining modals before extracting results...")
@@ -765,11 +830,18 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 
 	// Get current URL to understand which page we're on
 	url, _ := s.GetPageURL()
-	details["current_page_url"] = url
+	result.Diagnostics.PageURL = url
 
 	// First, check if there are any search results at all
 	log.Println("Checking for search results...")
-	var resultsData interface{}
+	var check struct {
+		HasResults         bool   `json:"hasResults"`
+		NoResultsMessage   string `json:"noResultsMessage"`
+		TableCount         int    `json:"tableCount"`
+		PropertyImages     int    `json:"propertyImages"`
+		RecruitingElements int    `json:"recruitingElements"`
+		ResultCount        int    `json:"resultCount"`
+	}
 
 	err := chromedp.Run(s.ctx,
 		chromedp.Evaluate(`
@@ -827,15 +899,12 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 										   recruitingElements > 0 ||
 										   propertyLinks.length > 0;
 				
-				// Also check for result count display (e.g., "1件")
+				// Also check for the labelled result count (e.g., "検索結果 12件")
 				let resultCount = 0;
-				for (let el of allEls) {
-					const text = el.textContent;
-					if (text && text.match(/(\d+)\s*件/) && !text.includes('0件')) {
-						const match = text.match(/(\d+)\s*件/);
-						resultCount = parseInt(match[1]);
-						break;
-					}
+				const pageText = document.body ? document.body.innerText : '';
+				const countMatch = pageText.match(/`+resultCountPattern+`/);
+				if (countMatch) {
+					resultCount = parseInt(countMatch[1].replace(/,/g, ''), 10);
 				}
 				
 				return {
@@ -847,157 +916,57 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 					resultCount: resultCount
 				};
 			})()
-		`, &resultsData),
+		`, &check),
 	)
 
 	if err != nil {
-		log.Printf("Error checking for results: %v\n", err)
-	} else {
-		// Parse the JavaScript result
-		if resultMap, ok := resultsData.(map[string]interface{}); ok {
-			if hasRes, ok := resultMap["hasResults"].(bool); ok && hasRes {
-				log.Println("Search results found - proceeding with extraction")
-				details["search_status"] = "Results found"
-			} else {
-				// Additional check - if we have detected property images or recruit status
-				if imgCount, ok := resultMap["propertyImages"].(float64); ok && imgCount > 0 {
-					log.Printf("Found %v property images - treating as results found\n", imgCount)
-					details["search_status"] = "Results found"
-				} else if recruitCount, ok := resultMap["recruitingElements"].(float64); ok && recruitCount > 0 {
-					log.Printf("Found %v recruiting elements - treating as results found\n", recruitCount)
-					details["search_status"] = "Results found"
-				} else if resultCount, ok := resultMap["resultCount"].(float64); ok && resultCount > 0 {
-					log.Printf("Found result count: %v - treating as results found\n", resultCount)
-					details["search_status"] = "Results found"
-				} else {
-					log.Println("No search results found")
-					details["search_status"] = "No results found"
-					if msg, ok := resultMap["noResultsMessage"].(string); ok && msg != "" {
-						details["no_results_message"] = msg
-					}
-				}
-			}
-			if tableCount, ok := resultMap["tableCount"].(float64); ok {
-				details["table_count"] = fmt.Sprintf("%.0f", tableCount)
-			}
-		}
+		return nil, fmt.Errorf("%w: checking for search results: %w", ErrExtractionFailed, err)
+	}
+	result.Diagnostics.TableCount = check.TableCount
+	result.ResultCount = check.ResultCount
+
+	switch {
+	case check.HasResults:
+		log.Println("Search results found - proceeding with extraction")
+		result.Status = SearchStatusFound
+	case check.PropertyImages > 0:
+		// Additional check - if we have detected property images or recruit status
+		log.Printf("Found %d property images - treating as results found\n", check.PropertyImages)
+		result.Status = SearchStatusFound
+	case check.RecruitingElements > 0:
+		log.Printf("Found %d recruiting elements - treating as results found\n", check.RecruitingElements)
+		result.Status = SearchStatusFound
+	case check.ResultCount > 0:
+		log.Printf("Found result count: %d - treating as results found\n", check.ResultCount)
+		result.Status = SearchStatusFound
+	default:
+		log.Println("No search results found")
+		result.Status = SearchStatusNoResults
+		result.NoResultsMessage = check.NoResultsMessage
 	}
 
 	// If no results found, return early
-	if details["search_status"] == "No results found" {
+	if result.Status == SearchStatusNoResults {
 		log.Println("No search results found - returning early")
 
 		// Get page title for context
-		var pageTitle string
 		chromedp.Run(s.ctx,
-			chromedp.Title(&pageTitle),
+			chromedp.Title(&result.Diagnostics.PageTitle),
 		)
-		if pageTitle != "" {
-			details["page_title"] = pageTitle
-		}
 
-		return details, nil
+		return result, nil
 	}
 
 	// ITANDI BB specific selectors for search results (only if results exist)
-	// Based on sample.png structure
-	selectors := map[string][]string{
-		"property_name": {
-			`td:contains("物件名") + td`, // Table cell after "物件名"
-			`.property-name`,
-			`[class*="building"]`,
-			`td[data-label*="物件名"]`,
-			`tr:contains("物件名") td:last-child`,
-		},
-		"building_number": {
-			`td:contains("部屋番号") + td`,
-			`.room-number`,
-			`tr:contains("部屋番号") td:last-child`,
-		},
-		"management_status": {
-			`td:contains("管理費") + td`,
-			`td:contains("共益費") + td`,
-			`tr:contains("管理費") td:last-child`,
-		},
-		"rent": {
-			`td:contains("賃料") + td`,
-			`.rent`,
-			`[class*="rent"]`,
-			`td[data-label*="賃料"]`,
-			`tr:contains("賃料") td:last-child`,
-		},
-		"deposit": {
-			`td:contains("敷金") + td`,
-			`.deposit`,
-			`tr:contains("敷金") td:last-child`,
-		},
-		"key_money": {
-			`td:contains("礼金") + td`,
-			`.key-money`,
-			`tr:contains("礼金") td:last-child`,
-		},
-		"insurance": {
-			`td:contains("保証金") + td`,
-			`.insurance`,
-			`tr:contains("保証金") td:last-child`,
-		},
-		"layout": {
-			`td:contains("間取り") + td`,
-			`.layout`,
-			`[class*="layout"]`,
-			`td[data-label*="間取"]`,
-			`tr:contains("間取り") td:last-child`,
-		},
-		"area": {
-			`td:contains("専有面積") + td`,
-			`.area`,
-			`[class*="area"]`,
-			`tr:contains("専有面積") td:last-child`,
-			`tr:contains("面積") td:last-child`,
-		},
-		"date_completed": {
-			`td:contains("築年月") + td`,
-			`td:contains("竣工年月") + td`,
-			`tr:contains("築年月") td:last-child`,
-		},
-		"available_date": {
-			`td:contains("入居可能時期") + td`,
-			`td:contains("入居可能日") + td`,
-			`tr:contains("入居可能") td:last-child`,
-		},
-		"vacancy_rate": {
-			`td:contains("空室率") + td`,
-			`td:contains("収引率") + td`,
-			`tr:contains("率") td:last-child`,
-		},
-		"floor_info": {
-			`td:contains("階") + td`,
-			`.floor-info`,
-			`tr:contains("階") td:last-child`,
-		},
-		"management_company": {
-			`td:contains("管理会社") + td`,
-			`.management-company`,
-			`[class*="management"]`,
-			`td[data-label*="管理会社"]`,
-			`tr:contains("管理会社") td:last-child`,
-		},
-		"photo_count": {
-			`span:contains("枚")`,
-			`.photo-count`,
-			`[class*="photo"] span`,
-		},
-	}
+	// Configured under "details.*" in the selector config
+	selectors := s.selectors.Config().Prefixed("details.")
 
 	// Try to get each piece of information
 	for key, selectorList := range selectors {
 		for _, selector := range selectorList {
-			var content string
-			err := chromedp.Run(s.ctx,
-				chromedp.Text(selector, &content, chromedp.ByQuery, chromedp.AtLeast(0)),
-			)
-			if err == nil && content != "" && content != " " {
-				details[key] = strings.TrimSpace(content)
+			content, err := textLocator(s.ctx, selector)
+			if err == nil && content != "" {
+				result.Fields[key] = strings.TrimSpace(content)
 				log.Printf("Found %s: %s (using selector: %s)\n", key, content, selector)
 				break
 			}
@@ -1005,59 +974,310 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 	}
 
 	// Try to get all property information using JavaScript for more flexibility
-	var propertyData interface{}
-	err = chromedp.Run(s.ctx,
-		chromedp.Evaluate(`
-			(() => {
-				const data = {};
-				
-				// Try to extract table-based data (like in sample.png)
-				const tables = document.querySelectorAll('table');
-				tables.forEach(table => {
-					const rows = table.querySelectorAll('tr');
-					rows.forEach(row => {
-						const cells = row.querySelectorAll('td, th');
-						if (cells.length >= 2) {
-							const label = cells[0].textContent.trim();
-							const value = cells[cells.length - 1].textContent.trim();
+	extraction, err := s.extractResultPage()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range extraction.Fields {
+		result.Fields[k] = v
+		log.Printf("Found %s: %s (from JavaScript)\n", k, v)
+	}
+
+	for _, raw := range extraction.Properties {
+		result.Listings = append(result.Listings, newPropertyListing(raw))
+	}
+	if len(result.Listings) > 0 {
+		s.collectResultPages(result)
+		log.Printf("Found %d properties with details\n", len(result.Listings))
+	} else if len(result.Fields) > 0 {
+		// A detail-style page has no cards, but its table describes a single room
+		result.Listings = append(result.Listings, newPropertyListing(result.Fields))
+	}
+
+	// Every card should have been read when the page reports a count
+	if result.ResultCount > 0 && len(result.Listings) != result.ResultCount {
+		result.Incomplete = true
+		log.Printf("Warning: extracted %d listings but the page reports %d件\n", len(result.Listings), result.ResultCount)
+	}
+
+	result.Diagnostics.PropertyElementsFound = extraction.Debug.PropertyElementsFound
+	result.Diagnostics.ImgElementsFound = extraction.Debug.ImgElementsFound
+	result.Diagnostics.PropertyLinks = extraction.Debug.PropertyLinks
+	result.Diagnostics.FirstLinkText = extraction.Debug.FirstLinkText
+	result.Diagnostics.FirstLinkHref = extraction.Debug.FirstLinkHref
+
+	// Final status check - if we already found results, don't override
+	if result.Status != SearchStatusFound {
+		// Try to get search result summary
+		var resultSummary string
+		chromedp.Run(s.ctx,
+			chromedp.Text(`body`, &resultSummary, chromedp.ByQuery),
+		)
+
+		if extraction.NoResults || zeroResultsPattern.MatchString(resultSummary) || strings.Contains(resultSummary, "該当する物件がありません") {
+			result.Status = SearchStatusNoResults
+			log.Println("No search results found")
+		} else if resultCountRe.MatchString(resultSummary) {
+			result.Status = SearchStatusFound
+		}
+	}
+
+	// Get page title
+	chromedp.Run(s.ctx,
+		chromedp.Title(&result.Diagnostics.PageTitle),
+	)
+
+	// Save search results DOM if we have results (focused on property cards area only)
+	if result.Status == SearchStatusFound {
+		var propertyCardHTML string
+		err = chromedp.Run(s.ctx,
+			chromedp.Evaluate(`
+				(() => {
+					// Find the complete property record but exclude desktop-only elements
+					var propertyLinks = document.querySelectorAll('a[href*="/rent_rooms/"]');
+					if (propertyLinks.length > 0) {
+						// Get the first property link's container
+						var link = propertyLinks[0];
+						var container = link;
+						
+						// Navigate up to find the most complete container
+						var bestContainer = null;
+						var maxScore = 0;
+						var attempts = 0;
+						
+						while (container.parentElement && attempts < 15) {
+							var parent = container.parentElement;
+							var text = parent.textContent || '';
+							var score = 0;
 							
-							// Map common labels
-							const labelMap = {
-								'物件名': 'property_name',
-								'部屋番号': 'room_number',
-								'賃料': 'rent',
-								'管理費': 'management_fee',
-								'共益費': 'common_fee',
-								'敷金': 'deposit',
-								'礼金': 'key_money',
-								'保証金': 'insurance',
-								'間取り': 'layout',
-								'専有面積': 'area',
-								'築年月': 'date_completed',
-								'入居可能時期': 'available_date',
-								'管理会社': 'management_company',
-								'建物種別': 'building_type',
-								'構造': 'structure',
-								'所在地': 'address',
-								'最寄駅': 'nearest_station',
-								'駅徒歩': 'station_distance',
-								'階数': 'floor',
-								'向き': 'direction',
-								'契約期間': 'contract_period',
-								'更新料': 'renewal_fee',
-								'広告料': 'advertisement_fee',
-								'部屋数': 'room_count'
-							};
+							// Score based on completeness of information
+							if (text.includes('クレール')) score += 10;
+							if (text.includes('大阪府大阪市住吉区')) score += 10;
+							if (text.includes('7.7万円')) score += 5;
+							if (text.includes('1LDK')) score += 3;
+							if (text.includes('44.61㎡')) score += 3;
+							if (text.includes('募集中')) score += 3;
+							if (text.includes('株式会社Room')) score += 8;
+							if (text.includes('築13年')) score += 5;
+							if (text.includes('2011年9月')) score += 5;
+							if (text.includes('部屋番号')) score += 3;
+							if (text.includes('敷礼保')) score += 3;
+							if (text.includes('内見・申込')) score += 3;
 							
-							const key = labelMap[label] || label.toLowerCase().replace(/\s+/g, '_');
-							if (value && value !== '') {
-								data[key] = value;
-							}
-						}
-					});
-				});
-				
-				// Also try to get list-style data
+							// Bonus for having complete action buttons
+							if (text.includes('部屋止') && text.includes('内見') && text.includes('詳細')) score += 5;
+							
+							// Accept containers with high completeness score
+							if (score > maxScore && score >= 30) {
+								maxScore = score;
+								bestContainer = parent;
+							}
+							
+							container = parent;
+							attempts++;
+							
+							// Stop if we're getting too high in the DOM
+							if (container.tagName === 'BODY' || container.children.length > 50) {
+								break;
+							}
+						}
+						
+						// Filter out desktop-only elements from the container
+						function filterDesktopElements(element) {
+							if (!element) return null;
+							
+							// Clone the element to avoid modifying the original DOM
+							var cloned = element.cloneNode(true);
+							
+							// Function to check if element is desktop-only based on CSS
+							function isDesktopOnly(el) {
+								// Check for CSS that indicates desktop-only display (@media screen and (min-width: 900px))
+								var computedStyle = window.getComputedStyle(el);
+								
+								// Look for elements that are only visible on desktop
+								// This includes checking parent containers that might have desktop-only CSS
+								var current = el;
+								while (current && current !== document.body) {
+									var style = window.getComputedStyle(current);
+									
+									// Check for common desktop-only patterns
+									// Elements with display properties that suggest desktop layout
+									if (style.display === 'table-cell' && window.innerWidth < 900) {
+										return true;
+									}
+									
+									// Check class names that might indicate desktop layout
+									var className = current.className || '';
+									if (typeof className === 'string' && 
+										(className.includes('desktop') || 
+										 className.includes('md-') || 
+										 className.includes('lg-'))) {
+										return true;
+									}
+									
+									current = current.parentElement;
+								}
+								
+								return false;
+							}
+							
+							// Remove desktop-only elements from the cloned tree
+							function removeDesktopOnlyElements(element) {
+								var elementsToRemove = [];
+								
+								// Check all descendants
+								var walker = document.createTreeWalker(
+									element,
+									NodeFilter.SHOW_ELEMENT,
+									null,
+									false
+								);
+								
+								var node;
+								while (node = walker.nextNode()) {
+									if (isDesktopOnly(node)) {
+										elementsToRemove.push(node);
+									}
+								}
+								
+								// Remove the elements
+								elementsToRemove.forEach(function(el) {
+									if (el.parentNode) {
+										el.parentNode.removeChild(el);
+									}
+								});
+								
+								return element;
+							}
+							
+							return removeDesktopOnlyElements(cloned);
+						}
+						
+						// Use the best container found, filter out desktop elements
+						if (bestContainer) {
+							var filtered = filterDesktopElements(bestContainer);
+							return filtered ? filtered.outerHTML : bestContainer.outerHTML;
+						} else {
+							// Fallback: try to find any container with basic info
+							var fallbackContainers = document.querySelectorAll('[class*="jss"]');
+							for (var i = 0; i < fallbackContainers.length; i++) {
+								var fallback = fallbackContainers[i];
+								var fallbackText = fallback.textContent || '';
+								if (fallbackText.includes('クレール') && 
+									fallbackText.includes('大阪') && 
+									fallbackText.includes('7.7万円') && 
+									fallbackText.includes('株式会社')) {
+									var filtered = filterDesktopElements(fallback);
+									return filtered ? filtered.outerHTML : fallback.outerHTML;
+								}
+							}
+						}
+					}
+					
+					return 'Property card not found';
+				})()
+			`, &propertyCardHTML),
+		)
+		if err != nil {
+			log.Printf("Error getting property card HTML: %v\n", err)
+		} else if propertyCardHTML != "Property card not found" {
+			// Save DOM to file with proper UTF-8 encoding
+			domFileName := fmt.Sprintf("property_card_dom_%s.html", time.Now().Format("20060102_150405"))
+			
+			// Add HTML header with UTF-8 charset declaration
+			htmlContent := `<!DOCTYPE html>
+<html lang="ja">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Property Card DOM</title>
+</head>
+<body>
+` + propertyCardHTML + `
+</body>
+</html>`
+			
+			if err := os.WriteFile(domFileName, []byte(htmlContent), 0644); err != nil {
+				log.Printf("Error saving DOM file: %v\n", err)
+			} else {
+				result.Diagnostics.DOMSavedTo = domFileName
+				fmt.Printf("Property card DOM saved to: %s (size: %d bytes)\n", domFileName, len(htmlContent))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// resultPageExtraction is what the in-page script reads from one page of search results
+type resultPageExtraction struct {
+	Fields     map[string]string   `json:"fields"`
+	Properties []map[string]string `json:"properties"`
+	NoResults  bool                `json:"noResults"`
+	Debug      struct {
+		PropertyElementsFound int    `json:"propertyElementsFound"`
+		ImgElementsFound      int    `json:"imgElementsFound"`
+		PropertyLinks         int    `json:"propertyLinks"`
+		FirstLinkText         string `json:"firstLinkText"`
+		FirstLinkHref         string `json:"firstLinkHref"`
+	} `json:"debug"`
+}
+
+// extractResultPage reads the label/value tables and the property cards of the current results page
+func (s *ITANDIScraper) extractResultPage() (*resultPageExtraction, error) {
+	var extraction resultPageExtraction
+	err := chromedp.Run(s.ctx,
+		chromedp.Evaluate(`
+			(() => {
+				const data = {};
+				
+				// Try to extract table-based data (like in sample.png)
+				const tables = document.querySelectorAll('table');
+				tables.forEach(table => {
+					const rows = table.querySelectorAll('tr');
+					rows.forEach(row => {
+						const cells = row.querySelectorAll('td, th');
+						if (cells.length >= 2) {
+							const label = cells[0].textContent.trim();
+							const value = cells[cells.length - 1].textContent.trim();
+							
+							// Map common labels
+							const labelMap = {
+								'物件名': 'property_name',
+								'部屋番号': 'room_number',
+								'賃料': 'rent',
+								'管理費': 'management_fee',
+								'共益費': 'common_fee',
+								'敷金': 'deposit',
+								'礼金': 'key_money',
+								'保証金': 'insurance',
+								'間取り': 'layout',
+								'専有面積': 'area',
+								'築年月': 'date_completed',
+								'入居可能時期': 'available_date',
+								'管理会社': 'management_company',
+								'建物種別': 'building_type',
+								'構造': 'structure',
+								'所在地': 'address',
+								'最寄駅': 'nearest_station',
+								'駅徒歩': 'station_distance',
+								'階数': 'floor',
+								'向き': 'direction',
+								'契約期間': 'contract_period',
+								'更新料': 'renewal_fee',
+								'広告料': 'advertisement_fee',
+								'部屋数': 'room_count'
+							};
+							
+							const key = labelMap[label] || label.toLowerCase().replace(/\s+/g, '_');
+							if (value && value !== '') {
+								data[key] = value;
+							}
+						}
+					});
+				});
+				
+				// Also try to get list-style data
 				const listItems = document.querySelectorAll('dl, .property-info, .detail-info');
 				listItems.forEach(item => {
 					const dts = item.querySelectorAll('dt');
@@ -1182,19 +1402,45 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 					}
 					
 					// Check for募集中 status
-					if (textContent.includes('募集中')) {
-						property.status = '募集中';
+					const statuses = ['募集中', '申込あり', '申込中', '成約済', '募集終了'];
+					const status = statuses.find(st => textContent.includes(st));
+					if (status) {
+						property.status = status;
 					}
 					
 					// Extract deposit/key money
-					const depositMatch = textContent.match(/敷金[：\s]*(\d+\.?\d*)\s*万円/);
+					const depositMatch = textContent.match(/敷金[：\s]*(\d+\.?\d*\s*(?:万円|ヶ月|ヵ月|か月)|なし|-)/);
 					if (depositMatch) {
-						property.deposit = depositMatch[1] + '万円';
+						property.deposit = depositMatch[1];
 					}
 					
-					const keyMoneyMatch = textContent.match(/礼金[：\s]*(\d+\.?\d*)\s*万円/);
+					const keyMoneyMatch = textContent.match(/礼金[：\s]*(\d+\.?\d*\s*(?:万円|ヶ月|ヵ月|か月)|なし|-)/);
 					if (keyMoneyMatch) {
-						property.key_money = keyMoneyMatch[1] + '万円';
+						property.key_money = keyMoneyMatch[1];
+					}
+					
+					// Extract management fee (管理費/共益費)
+					const feeMatch = textContent.match(/(?:管理費|共益費)[：\s]*([\d,]+\.?\d*\s*(?:万円|円))/);
+					if (feeMatch) {
+						property.management_fee = feeMatch[1];
+					}
+					
+					// Extract room number (部屋番号)
+					const roomMatch = textContent.match(/部屋番号[：\s]*([^\s]+)/) || textContent.match(/(\d+)号室/);
+					if (roomMatch) {
+						property.room_number = roomMatch[1];
+					}
+					
+					// Extract availability (入居可能時期)
+					const availableMatch = textContent.match(/入居(?:可能)?(?:時期|日)?[：\s]*(即[^\s]*|相談|\d{4}[年\/]\d{1,2}月?[^\s]*)/);
+					if (availableMatch) {
+						property.available_date = availableMatch[1];
+					}
+					
+					// Extract management company
+					const companyMatch = textContent.match(/(株式会社[^\s]+|[^\s]+株式会社)/);
+					if (companyMatch) {
+						property.management_company = companyMatch[1];
 					}
 					
 					// Only add if we found some property info
@@ -1204,276 +1450,109 @@ func (s *ITANDIScraper) GetPropertyDetails() (map[string]string, error) {
 				});
 				
 				// Add debug info
-				data.debug_property_elements_found = propertyElements.length;
-				data.debug_img_elements_found = imgElements.length;
+				const debug = {
+					propertyElementsFound: propertyElements.length,
+					imgElementsFound: imgElements.length
+				};
 				
-				if (properties.length > 0) {
-					data.properties = properties;
-					data.property_count = properties.length;
-					
-					// Add first property details to root level for easy access
-					if (properties[0]) {
-						Object.keys(properties[0]).forEach(key => {
-							data['first_property_' + key] = properties[0][key];
-						});
-					}
-				} else {
+				if (properties.length === 0) {
 					// Debug: Try to find any property-related content
 					const allLinks = document.querySelectorAll('a[href*="/rent_rooms/"]');
-					data.debug_property_links = allLinks.length;
+					debug.propertyLinks = allLinks.length;
 					
 					// Get sample content for debugging
 					if (allLinks.length > 0) {
-						data.debug_first_link_text = allLinks[0].textContent.trim();
-						data.debug_first_link_href = allLinks[0].href;
+						debug.firstLinkText = allLinks[0].textContent.trim();
+						debug.firstLinkHref = allLinks[0].href;
 					}
 				}
 				
 				// Check for no results message
 				const bodyText = document.body.textContent;
-				if (bodyText.includes('検索結果がありません') || bodyText.includes('該当する物件がありません')) {
-					data.no_results = true;
-				}
+				const noResults = bodyText.includes('検索結果がありません') || bodyText.includes('該当する物件がありません');
 				
-				return data;
+				return {
+					fields: data,
+					properties: properties,
+					noResults: noResults,
+					debug: debug
+				};
 			})()
-		`, &propertyData),
+		`, &extraction),
 	)
-	
-	if err == nil && propertyData != nil {
-		if dataMap, ok := propertyData.(map[string]interface{}); ok {
-			// Merge JavaScript extracted data with details
-			for k, v := range dataMap {
-				if strVal, ok := v.(string); ok && strVal != "" {
-					details[k] = strVal
-					log.Printf("Found %s: %s (from JavaScript)\n", k, strVal)
-				} else if k == "properties" {
-					// Handle property list with details
-					if list, ok := v.([]interface{}); ok && len(list) > 0 {
-						details["property_count"] = fmt.Sprintf("%d", len(list))
-						log.Printf("Found %d properties with details\n", len(list))
-						
-						// Store the full property list as JSON
-						if jsonData, err := json.Marshal(list); err == nil {
-							details["properties_json"] = string(jsonData)
-						}
-					}
-				} else if strings.HasPrefix(k, "first_property_") {
-					// Add first property details to main details
-					details[k] = strVal
-					log.Printf("First property %s: %s\n", strings.TrimPrefix(k, "first_property_"), strVal)
-				}
-			}
-		}
+	if err != nil {
+		return nil, fmt.Errorf("%w: property data: %w", ErrExtractionFailed, err)
 	}
+	return &extraction, nil
+}
 
-	// Final status check - if we already found results, don't override
-	if details["search_status"] != "Results found" {
-		// Try to get search result summary
-		var resultSummary string
-		chromedp.Run(s.ctx,
-			chromedp.Text(`body`, &resultSummary, chromedp.ByQuery),
-		)
-
-		if strings.Contains(resultSummary, "0件") || strings.Contains(resultSummary, "該当する物件がありません") {
-			details["search_status"] = "No results found"
-			log.Println("No search results found")
-		} else if strings.Contains(resultSummary, "件") {
-			details["search_status"] = "Results found"
-		}
+// GetRoomDetails extracts the search results like GetPropertyDetails,
+// keeps the building that best matches the searched name with
+// SearchResult.SelectBuilding and narrows it to room with
+// SearchResult.SelectRoom. When the room is listed
+// its card is scrolled into view so the next screenshot shows it. With
+// detail pages enabled, the remaining listings' 詳細 pages are read too.
+func (s *ITANDIScraper) GetRoomDetails(room string) (*SearchResult, error) {
+	result, err := s.GetPropertyDetails()
+	if err != nil {
+		return result, err
 	}
 
-	// Get page title
-	var pageTitle string
-	chromedp.Run(s.ctx,
-		chromedp.Title(&pageTitle),
-	)
-	if pageTitle != "" {
-		details["page_title"] = pageTitle
+	if s.target.Name != "" {
+		result.SelectBuilding(s.target)
+		if m := result.Match; m != nil {
+			if m.Ambiguous {
+				log.Printf("Warning: %q matches the results ambiguously, keeping all listings: %s\n", m.Requested, m)
+			} else {
+				log.Printf("Best match for %q: %s\n", m.Requested, m)
+			}
+		}
 	}
 
-	// Save search results DOM if we have results (focused on property cards area only)
-	if details["search_status"] == "Results found" {
-		var propertyCardHTML string
-		err = chromedp.Run(s.ctx,
-			chromedp.Evaluate(`
-				(() => {
-					// Find the complete property record but exclude desktop-only elements
-					var propertyLinks = document.querySelectorAll('a[href*="/rent_rooms/"]');
-					if (propertyLinks.length > 0) {
-						// Get the first property link's container
-						var link = propertyLinks[0];
-						var container = link;
-						
-						// Navigate up to find the most complete container
-						var bestContainer = null;
-						var maxScore = 0;
-						var attempts = 0;
-						
-						while (container.parentElement && attempts < 15) {
-							var parent = container.parentElement;
-							var text = parent.textContent || '';
-							var score = 0;
-							
-							// Score based on completeness of information
-							if (text.includes('クレール')) score += 10;
-							if (text.includes('大阪府大阪市住吉区')) score += 10;
-							if (text.includes('7.7万円')) score += 5;
-							if (text.includes('1LDK')) score += 3;
-							if (text.includes('44.61㎡')) score += 3;
-							if (text.includes('募集中')) score += 3;
-							if (text.includes('株式会社Room')) score += 8;
-							if (text.includes('築13年')) score += 5;
-							if (text.includes('2011年9月')) score += 5;
-							if (text.includes('部屋番号')) score += 3;
-							if (text.includes('敷礼保')) score += 3;
-							if (text.includes('内見・申込')) score += 3;
-							
-							// Bonus for having complete action buttons
-							if (text.includes('部屋止') && text.includes('内見') && text.includes('詳細')) score += 5;
-							
-							// Accept containers with high completeness score
-							if (score > maxScore && score >= 30) {
-								maxScore = score;
-								bestContainer = parent;
-							}
-							
-							container = parent;
-							attempts++;
-							
-							// Stop if we're getting too high in the DOM
-							if (container.tagName === 'BODY' || container.children.length > 50) {
-								break;
-							}
-						}
-						
-						// Filter out desktop-only elements from the container
-						function filterDesktopElements(element) {
-							if (!element) return null;
-							
-							// Clone the element to avoid modifying the original DOM
-							var cloned = element.cloneNode(true);
-							
-							// Function to check if element is desktop-only based on CSS
-							function isDesktopOnly(el) {
-								// Check for CSS that indicates desktop-only display (@media screen and (min-width: 900px))
-								var computedStyle = window.getComputedStyle(el);
-								
-								// Look for elements that are only visible on desktop
-								// This includes checking parent containers that might have desktop-only CSS
-								var current = el;
-								while (current && current !== document.body) {
-									var style = window.getComputedStyle(current);
-									
-									// Check for common desktop-only patterns
-									// Elements with display properties that suggest desktop layout
-									if (style.display === 'table-cell' && window.innerWidth < 900) {
-										return true;
-									}
-									
-									// Check class names that might indicate desktop layout
-									var className = current.className || '';
-									if (typeof className === 'string' && 
-										(className.includes('desktop') || 
-										 className.includes('md-') || 
-										 className.includes('lg-'))) {
-										return true;
-									}
-									
-									current = current.parentElement;
-								}
-								
-								return false;
-							}
-							
-							// Remove desktop-only elements from the cloned tree
-							function removeDesktopOnlyElements(element) {
-								var elementsToRemove = [];
-								
-								// Check all descendants
-								var walker = document.createTreeWalker(
-									element,
-									NodeFilter.SHOW_ELEMENT,
-									null,
-									false
-								);
-								
-								var node;
-								while (node = walker.nextNode()) {
-									if (isDesktopOnly(node)) {
-										elementsToRemove.push(node);
-									}
-								}
-								
-								// Remove the elements
-								elementsToRemove.forEach(function(el) {
-									if (el.parentNode) {
-										el.parentNode.removeChild(el);
-									}
-								});
-								
-								return element;
-							}
-							
-							return removeDesktopOnlyElements(cloned);
-						}
-						
-						// Use the best container found, filter out desktop elements
-						if (bestContainer) {
-							var filtered = filterDesktopElements(bestContainer);
-							return filtered ? filtered.outerHTML : bestContainer.outerHTML;
-						} else {
-							// Fallback: try to find any container with basic info
-							var fallbackContainers = document.querySelectorAll('[class*="jss"]');
-							for (var i = 0; i < fallbackContainers.length; i++) {
-								var fallback = fallbackContainers[i];
-								var fallbackText = fallback.textContent || '';
-								if (fallbackText.includes('クレール') && 
-									fallbackText.includes('大阪') && 
-									fallbackText.includes('7.7万円') && 
-									fallbackText.includes('株式会社')) {
-									var filtered = filterDesktopElements(fallback);
-									return filtered ? filtered.outerHTML : fallback.outerHTML;
-								}
-							}
-						}
-					}
-					
-					return 'Property card not found';
-				})()
-			`, &propertyCardHTML),
-		)
-		if err != nil {
-			log.Printf("Error getting property card HTML: %v\n", err)
-		} else if propertyCardHTML != "Property card not found" {
-			// Save DOM to file with proper UTF-8 encoding
-			domFileName := fmt.Sprintf("property_card_dom_%s.html", time.Now().Format("20060102_150405"))
-			
-			// Add HTML header with UTF-8 charset declaration
-			htmlContent := `<!DOCTYPE html>
-<html lang="ja">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Property Card DOM</title>
-</head>
-<body>
-` + propertyCardHTML + `
-</body>
-</html>`
-			
-			if err := os.WriteFile(domFileName, []byte(htmlContent), 0644); err != nil {
-				log.Printf("Error saving DOM file: %v\n", err)
-			} else {
-				details["dom_saved_to"] = domFileName
-				fmt.Printf("Property card DOM saved to: %s (size: %d bytes)\n", domFileName, len(htmlContent))
+	if room != "" {
+		if err := result.SelectRoom(room); err != nil {
+			return result, err
+		}
+		switch result.Status {
+		case SearchStatusFound:
+			log.Printf("Room %s is listed\n", room)
+			if len(result.Listings) > 0 {
+				s.scrollToListing(result.Listings[0])
+			}
+		case SearchStatusRoomNotListed:
+			if result.Incomplete {
+				// The room may be on a result page that could not be read
+				return result, fmt.Errorf("%w: room %s not among %d listings of %d件", ErrIncompleteResults, room, len(result.OtherRooms), result.ResultCount)
 			}
+			log.Printf("Room %s is not listed (listed rooms: %s)\n", room, strings.Join(result.OtherRooms, ", "))
 		}
 	}
 
-	return details, nil
+	if s.detailPages {
+		s.fetchListingDetails(result)
+	}
+	return result, nil
 }
 
+// scrollToListing scrolls the result card linking to the listing into view.
+// It is best effort: a listing without a URL or card is left where it is.
+func (s *ITANDIScraper) scrollToListing(listing PropertyListing) {
+	if listing.URL == "" {
+		return
+	}
+	var found bool
+	err := chromedp.Run(s.ctx, chromedp.Evaluate(fmt.Sprintf(`
+		(() => {
+			const link = Array.from(document.querySelectorAll('a[href]')).find(a => a.href === %q);
+			if (!link) return false;
+			link.scrollIntoView({block: 'center'});
+			return true;
+		})()
+	`, listing.URL), &found))
+	if err != nil || !found {
+		log.Printf("Warning: could not scroll to room %s\n", listing.RoomNumber)
+	}
+}
 
 // GetPageURL returns the current page URL
 func (s *ITANDIScraper) GetPageURL() (string, error) {
@@ -1491,12 +1570,18 @@ func (s *ITANDIScraper) WaitForNavigation() error {
 	)
 }
 
+// quickModalTimeout is how long closeModalAdsQuick waits for a modal to appear
+const quickModalTimeout = time.Second
+
 // closeModalAdsQuick quickly closes modal advertisements with timeout
 func (s *ITANDIScraper) closeModalAdsQuick() error {
 	log.Println("Quick modal check...")
 
-	// Very short wait
-	time.Sleep(500 * time.Millisecond)
+	// Most pages have no modal, so only look briefly for one to show up
+	if err := waitFor(s.ctx, "quick modal check", quickModalTimeout, waitModalShown(s.selectors.Get("modal.close"))); err != nil {
+		log.Println("No modal to close")
+		return nil
+	}
 
 	// Try direct JavaScript approach with short timeout
 	jsCtx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
@@ -1578,39 +1663,25 @@ func (s *ITANDIScraper) closeModalAdsQuick() error {
 func (s *ITANDIScraper) closeModalAds() error {
 	log.Println("Attempting to close modal advertisements...")
 
-	// Give modals time to appear (reduced from 2 seconds)
-	time.Sleep(1 * time.Second)
+	// Give modals time to appear
+	if err := s.waitPageSettled("modal check"); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	// Try a simple, robust approach
 	var modalsClosed int
 
 	// Method 1: Try common close button selectors
-	closeSelectors := []string{
-		`button[aria-label="close"]`,
-		`button[title="close"]`,
-		`button[class*="close"]`,
-		`.close-button`,
-		`.modal-close`,
-		`[role="dialog"] button`,
-		`button:contains("×")`,
-		`button:contains("✕")`,
-		`span:contains("×")`,
-		`a:contains("×")`,
-		// ITANDI BB specific modal close patterns
-		`.modal .close`,
-		`[class*="modal"] [class*="close"]`,
-		`div[style*="position: fixed"] button`,
-		`div[style*="position: absolute"] button`,
-	}
+	closeSelectors := s.selectors.Get("modal.close_all")
 
 	for _, selector := range closeSelectors {
-		err := chromedp.Run(s.ctx,
-			chromedp.Click(selector, chromedp.ByQuery, chromedp.AtLeast(0)),
-		)
+		err := clickLocator(s.ctx, selector)
 		if err == nil {
 			log.Printf("Closed modal using selector: %s\n", selector)
 			modalsClosed++
-			time.Sleep(500 * time.Millisecond)
+			if waitFor(s.ctx, "modal", s.waits.Modal, waitModalGone()) == nil {
+				break
+			}
 		}
 	}
 
@@ -1687,8 +1758,10 @@ func (s *ITANDIScraper) closeModalAds() error {
 	// Method 3: Try keyboard shortcuts
 	chromedp.Run(s.ctx, chromedp.KeyEvent("\x1b")) // Escape
 
-	// Give time for everything to settle (reduced)
-	time.Sleep(500 * time.Millisecond)
+	// Give time for everything to settle
+	if err := waitFor(s.ctx, "modal", s.waits.Modal, waitModalGone()); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
 
 	log.Printf("Modal closing attempt completed (closed %d via selectors)\n", modalsClosed)
 	return nil
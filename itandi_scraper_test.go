@@ -0,0 +1,478 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockServerLoginFlow(t *testing.T) {
+	srv := newMockITANDIServer(t)
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	get := func(path string) (string, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Request.URL.Path, string(body)
+	}
+
+	if path, _ := get("/rent_rooms/list"); path != "/" {
+		t.Fatalf("list page without session ended at %q, want login page", path)
+	}
+
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"email": {mockEmail}, "password": {"wrong"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong password status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/login", url.Values{"email": {mockEmail}, "password": {mockPassword}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Request.URL.Path != "/top" {
+		t.Fatalf("login ended at %q, want /top", resp.Request.URL.Path)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/rent_rooms/list", "イタンジ売却査定"},
+		{"/rent_rooms/list?name=" + url.QueryEscape("クレール"), "クレール住吉"},
+		{"/rent_rooms/list?name=" + url.QueryEscape("存在しない"), "検索結果がありませんでした"},
+	}
+	for _, tt := range tests {
+		if _, body := get(tt.path); !strings.Contains(body, tt.want) {
+			t.Errorf("GET %s: body does not contain %q", tt.path, tt.want)
+		}
+	}
+}
+
+// mockBrowserConfig returns a headless browser config logging in with the
+// mock credentials, skipping the test when no Chromium is installed.
+// Screenshots and DOM dumps go to a temp dir.
+func mockBrowserConfig(t *testing.T) BrowserConfig {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping browser test in short mode")
+	}
+	cfg := DefaultBrowserConfig()
+	cfg.Headless = true
+	if cfg.ResolveExecPath() == "" {
+		t.Skip("Chromium not found - set CHROMIUM_PATH to run browser tests")
+	}
+
+	origEmail, origPassword := loginEmail, loginPassword
+	loginEmail, loginPassword = mockEmail, mockPassword
+	t.Cleanup(func() {
+		loginEmail, loginPassword = origEmail, origPassword
+	})
+
+	t.Chdir(t.TempDir())
+	return cfg
+}
+
+// mockWaitTimeouts are short limits for the instantly answering mock
+var mockWaitTimeouts = WaitTimeouts{
+	Page:    5 * time.Second,
+	Login:   5 * time.Second,
+	Search:  5 * time.Second,
+	Results: 5 * time.Second,
+	Modal:   2 * time.Second,
+}
+
+// newMockScraper starts a headless scraper pointed at srv
+func newMockScraper(t *testing.T, srv *mockITANDIServer) *ITANDIScraper {
+	t.Helper()
+
+	cfg := mockBrowserConfig(t)
+	scraper, err := NewITANDIScraperWithConfig(cfg, srv.SiteConfig(), SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to create scraper: %v", err)
+	}
+	t.Cleanup(scraper.Close)
+
+	// The mock answers instantly; short limits keep failing tests fast
+	scraper.UseWaitTimeouts(mockWaitTimeouts)
+	// The mock is deterministic; tests that exercise retries opt in
+	scraper.UseRetryPolicy(RetryPolicy{MaxAttempts: 1, Multiplier: 1})
+	return scraper
+}
+
+// loginToMock runs the login steps used by main
+func loginToMock(t *testing.T, scraper *ITANDIScraper) {
+	t.Helper()
+	if err := scraper.NavigateToLogin(); err != nil {
+		t.Fatalf("NavigateToLogin: %v", err)
+	}
+	if err := scraper.Login(); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if url, _ := scraper.GetPageURL(); !strings.HasSuffix(url, "/top") {
+		t.Fatalf("after login URL = %q, want top page", url)
+	}
+}
+
+func TestScraperSearchFound(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("クレール"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetPropertyDetails()
+	if err != nil {
+		t.Fatalf("GetPropertyDetails: %v", err)
+	}
+
+	if result.Status != SearchStatusFound {
+		t.Fatalf("Status = %q, want %q", result.Status, SearchStatusFound)
+	}
+	if len(result.Listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(result.Listings))
+	}
+	// The notice badge's "12件" is not the result count
+	if result.ResultCount != 1 {
+		t.Errorf("ResultCount = %d, want 1", result.ResultCount)
+	}
+	l := result.Listings[0]
+	if l.Name != "クレール住吉" || l.RoomNumber != "302" || l.Rent != 77000 || l.ManagementFee != 5000 {
+		t.Errorf("listing = %+v", l)
+	}
+	if l.Layout != "1LDK" || l.AreaSqm != 44.61 || l.Floor != 3 || !l.Recruiting() {
+		t.Errorf("listing = %+v", l)
+	}
+	if l.ManagementCompany != "株式会社Room" {
+		t.Errorf("ManagementCompany = %q", l.ManagementCompany)
+	}
+}
+
+func TestScraperRoomDetails(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("クレール"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetRoomDetails("302号室")
+	if err != nil {
+		t.Fatalf("GetRoomDetails: %v", err)
+	}
+	if result.Status != SearchStatusFound || len(result.Listings) != 1 || result.Listings[0].RoomNumber != "302" {
+		t.Fatalf("302: status = %q, listings = %+v", result.Status, result.Listings)
+	}
+
+	result, err = scraper.GetRoomDetails("101")
+	if err != nil {
+		t.Fatalf("GetRoomDetails: %v", err)
+	}
+	if result.Status != SearchStatusRoomNotListed {
+		t.Fatalf("101: status = %q, want %q", result.Status, SearchStatusRoomNotListed)
+	}
+	if len(result.Listings) != 0 || !slices.Equal(result.OtherRooms, []string{"302"}) {
+		t.Errorf("101: listings = %+v, other rooms = %v", result.Listings, result.OtherRooms)
+	}
+}
+
+func TestScraperDetailPages(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	scraper.UseDetailPages(true)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("クレール"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetRoomDetails("302")
+	if err != nil {
+		t.Fatalf("GetRoomDetails: %v", err)
+	}
+	if len(result.Listings) != 1 || result.Diagnostics.DetailPagesRead != 1 {
+		t.Fatalf("listings = %d, detail pages read = %d (failed %d)", len(result.Listings), result.Diagnostics.DetailPagesRead, result.Diagnostics.DetailPagesFailed)
+	}
+
+	d := result.Listings[0].Detail
+	if d == nil {
+		t.Fatal("Detail is nil")
+	}
+	if d.ContractType != "普通借家" || d.RenewalFee != "新賃料の1ヶ月分" || d.KeyExchangeFee != 22000 {
+		t.Errorf("contract = %q, renewal = %q, key exchange = %d", d.ContractType, d.RenewalFee, d.KeyExchangeFee)
+	}
+	if d.GuarantorCompany != "日本賃貸保証（加入必須）" || d.PetCondition != "小型犬・猫1匹まで相談" || d.InstrumentCondition != "不可" {
+		t.Errorf("guarantor = %q, pets = %q, instruments = %q", d.GuarantorCompany, d.PetCondition, d.InstrumentCondition)
+	}
+	if d.Direction != "南東" || d.Structure != "RC造" || d.BuiltYear != 2011 {
+		t.Errorf("direction = %q, structure = %q, built year = %d", d.Direction, d.Structure, d.BuiltYear)
+	}
+	want := []string{"エアコン", "バス・トイレ別", "独立洗面台", "オートロック", "宅配ボックス"}
+	if !slices.Equal(d.Facilities, want) {
+		t.Errorf("Facilities = %v, want %v", d.Facilities, want)
+	}
+
+	// The search results stay in the original tab
+	if url, _ := scraper.GetPageURL(); !strings.Contains(url, "/rent_rooms/list") {
+		t.Errorf("URL after detail pages = %q, want the results page", url)
+	}
+}
+
+func TestScraperSearchWalksResultPages(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("クレール長居"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetPropertyDetails()
+	if err != nil {
+		t.Fatalf("GetPropertyDetails: %v", err)
+	}
+
+	var rooms []string
+	for _, l := range result.Listings {
+		rooms = append(rooms, l.RoomNumber)
+	}
+	if !slices.Equal(rooms, []string{"101", "102", "201"}) {
+		t.Errorf("rooms = %v, want 101, 102 and 201", rooms)
+	}
+	if result.ResultCount != 3 || result.Diagnostics.ResultPages != 2 || result.Incomplete {
+		t.Errorf("count = %d, pages = %d, incomplete = %v", result.ResultCount, result.Diagnostics.ResultPages, result.Incomplete)
+	}
+}
+
+func TestScraperRoomOnUnreadPageIsNotConclusive(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	srv.LastPageMissing = true
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("クレール長居"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetRoomDetails("201")
+	if !errors.Is(err, ErrIncompleteResults) {
+		t.Fatalf("GetRoomDetails err = %v, want ErrIncompleteResults", err)
+	}
+	if !result.Incomplete || result.ResultCount != 3 {
+		t.Errorf("incomplete = %v, count = %d", result.Incomplete, result.ResultCount)
+	}
+}
+
+func TestScraperSearchNormalizesName(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("ｸﾚｰﾙ長居"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	if name := srv.LastSearch().Get("name"); name != "クレール長居" {
+		t.Errorf("typed name = %q, want クレール長居", name)
+	}
+
+	result, err := scraper.GetRoomDetails("")
+	if err != nil {
+		t.Fatalf("GetRoomDetails: %v", err)
+	}
+	m := result.Match
+	if m == nil || m.Name != "クレール長居" || m.Score != 1 || m.Ambiguous || m.Requested != "ｸﾚｰﾙ長居" {
+		t.Fatalf("Match = %+v", m)
+	}
+	if result.Status != SearchStatusFound || len(result.Listings) != 3 {
+		t.Errorf("status = %q, %d listings, want found with 3", result.Status, len(result.Listings))
+	}
+}
+
+func TestScraperSearchGenerationMismatchIsAmbiguous(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	// The generation and 棟 are left out of the search, and the only
+	// building found has neither, so it must not be reported as found
+	if err := scraper.SearchProperty("第２クレール長居 A棟"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	if name := srv.LastSearch().Get("name"); name != "クレール長居" {
+		t.Errorf("typed name = %q, want クレール長居", name)
+	}
+
+	result, err := scraper.GetRoomDetails("302")
+	if err != nil {
+		t.Fatalf("GetRoomDetails: %v", err)
+	}
+	m := result.Match
+	if m == nil || m.Name != "クレール長居" || !m.Ambiguous || m.Score >= minMatchScore {
+		t.Fatalf("Match = %+v, want a weak match", m)
+	}
+	if result.Status != SearchStatusAmbiguousMatch || !errors.Is(result.Err(), ErrAmbiguousMatch) {
+		t.Errorf("status = %q, err = %v, want ambiguous_match", result.Status, result.Err())
+	}
+	// The room is not looked for in a building that may be the wrong one
+	if len(result.Listings) != 3 || result.OtherRooms != nil {
+		t.Errorf("listings = %d, other rooms = %v", len(result.Listings), result.OtherRooms)
+	}
+}
+
+func TestScraperSearchByCriteria(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	criteria := SearchCriteria{
+		Prefecture:     "大阪府",
+		Station:        "長居",
+		WalkMinutes:    10,
+		RentMax:        80000,
+		Layouts:        []string{"1K", "1ldk"},
+		AreaMin:        25,
+		BuildingAgeMax: 20,
+		RecruitingOnly: true,
+	}
+	if err := scraper.Search(criteria); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	query := srv.LastSearch()
+	for param, want := range map[string]string{
+		"name":            "",
+		"prefecture":      "27",
+		"station":         "長居",
+		"walk_minutes":    "10",
+		"rent_min":        "",
+		"rent_max":        "80000",
+		"area_min":        "25",
+		"building_age":    "20",
+		"recruiting_only": "1",
+	} {
+		if got := query.Get(param); got != want {
+			t.Errorf("%s = %q, want %q", param, got, want)
+		}
+	}
+	if layouts := query["layout"]; !slices.Equal(layouts, []string{"1K", "1LDK"}) {
+		t.Errorf("layout = %v, want 1K and 1LDK", layouts)
+	}
+
+	result, err := scraper.GetPropertyDetails()
+	if err != nil {
+		t.Fatalf("GetPropertyDetails: %v", err)
+	}
+	if len(result.Listings) != 3 {
+		t.Errorf("got %d listings, want 3", len(result.Listings))
+	}
+}
+
+func TestScraperSearchCriteriaControlMissing(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	srv.Modal = false
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	// The mock form has no 2DK checkbox
+	err := scraper.Search(SearchCriteria{Prefecture: "大阪府", Layouts: []string{"2DK"}})
+	if !errors.Is(err, ErrSelectorNotFound) {
+		t.Fatalf("Search err = %v, want ErrSelectorNotFound", err)
+	}
+}
+
+func TestScraperSearchNoResults(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	srv.Modal = false
+	scraper := newMockScraper(t, srv)
+	loginToMock(t, scraper)
+
+	if err := scraper.SearchProperty("存在しない物件"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetPropertyDetails()
+	if err != nil {
+		t.Fatalf("GetPropertyDetails: %v", err)
+	}
+	if result.Status != SearchStatusNoResults {
+		t.Fatalf("Status = %q, want %q", result.Status, SearchStatusNoResults)
+	}
+	if !errors.Is(result.Err(), ErrNoResults) {
+		t.Errorf("Err() = %v, want ErrNoResults", result.Err())
+	}
+	if len(result.Listings) != 0 {
+		t.Errorf("got %d listings, want none", len(result.Listings))
+	}
+}
+
+func TestScraperPhoneVerificationDetected(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	srv.PhoneVerification = true
+	scraper := newMockScraper(t, srv)
+
+	if err := scraper.NavigateToLogin(); err != nil {
+		t.Fatalf("NavigateToLogin: %v", err)
+	}
+	if err := scraper.Login(); !errors.Is(err, ErrPhoneVerificationRequired) {
+		t.Fatalf("Login error = %v, want ErrPhoneVerificationRequired", err)
+	}
+}
+
+func TestScraperSessionExpired(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+
+	// Without logging in the mock redirects ITANDI BB pages to the login form
+	if err := scraper.SearchProperty("クレール"); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("SearchProperty error = %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestScraperSearchRetriesAfterSessionExpired(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	scraper.UseRetryPolicy(RetryPolicy{MaxAttempts: 2, Multiplier: 1, RetryOn: []string{"session_expired"}})
+
+	// The first attempt finds the login form; recovery logs in and the second attempt searches
+	if err := scraper.SearchProperty("クレール"); err != nil {
+		t.Fatalf("SearchProperty: %v", err)
+	}
+	result, err := scraper.GetPropertyDetails()
+	if err != nil {
+		t.Fatalf("GetPropertyDetails: %v", err)
+	}
+	if len(result.Listings) != 1 {
+		t.Fatalf("got %d listings, want 1", len(result.Listings))
+	}
+}
+
+func TestScraperLoginRejected(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	scraper := newMockScraper(t, srv)
+	loginPassword = "wrong"
+
+	if err := scraper.NavigateToLogin(); err != nil {
+		t.Fatalf("NavigateToLogin: %v", err)
+	}
+	if err := scraper.Login(); !errors.Is(err, ErrLoginRejected) {
+		t.Fatalf("Login error = %v, want ErrLoginRejected", err)
+	}
+	if url, _ := scraper.GetPageURL(); strings.HasSuffix(url, "/top") {
+		t.Fatalf("reached top page with a wrong password")
+	}
+}
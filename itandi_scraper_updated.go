@@ -14,27 +14,27 @@ import (
 type ITANDIScraperUpdated struct {
 	ctx    context.Context
 	cancel context.CancelFunc
+	site   SiteConfig
 }
 
 // NewITANDIScraperUpdated creates a new updated scraper instance
 func NewITANDIScraperUpdated(headless bool) (*ITANDIScraperUpdated, error) {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath("/Applications/Chromium.app/Contents/MacOS/Chromium"),
-		chromedp.Flag("headless", headless),
-	)
-
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, cancel2 := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	cfg := DefaultBrowserConfig()
+	cfg.Headless = headless
+	return NewITANDIScraperUpdatedWithConfig(cfg, DefaultSiteConfig())
+}
 
-	// Create a combined cancel function
-	combinedCancel := func() {
-		cancel2()
-		cancel()
+// NewITANDIScraperUpdatedWithConfig creates a new updated scraper instance from a browser config and site endpoints
+func NewITANDIScraperUpdatedWithConfig(cfg BrowserConfig, site SiteConfig) (*ITANDIScraperUpdated, error) {
+	ctx, cancel, err := newBrowserContext(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ITANDIScraperUpdated{
 		ctx:    ctx,
-		cancel: combinedCancel,
+		cancel: cancel,
+		site:   site,
 	}, nil
 }
 
@@ -48,7 +48,7 @@ func (s *ITANDIScraperUpdated) NavigateToLogin() error {
 	log.Println("Navigating to ITANDI login page...")
 
 	err := chromedp.Run(s.ctx,
-		chromedp.Navigate(loginURL),
+		chromedp.Navigate(s.site.LoginURL()),
 		chromedp.WaitReady("body"),
 	)
 	
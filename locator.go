@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Locators are the strings used in selectors.json. Besides plain CSS they support:
+//
+//	css=<selector>        CSS, including jQuery-style :contains("text") and :not(:contains("text"))
+//	xpath=<expression>    XPath (expressions starting with "//" or "(//" are detected automatically)
+//	text=<text>           innermost elements whose text contains <text>
+//	label=<text>          form control associated with a label, aria-label or placeholder containing <text>
+//	role=<role>[name="x"] elements with an explicit or implicit ARIA role and accessible name containing x
+//
+// Locators are resolved in the page by locatorEngineJS, so queries that
+// document.querySelector rejects (such as :contains) still match.
+
+// LocatorKind は locator の種類
+type LocatorKind string
+
+const (
+	LocatorCSS   LocatorKind = "css"
+	LocatorXPath LocatorKind = "xpath"
+	LocatorText  LocatorKind = "text"
+	LocatorLabel LocatorKind = "label"
+	LocatorRole  LocatorKind = "role"
+)
+
+// clickTimeout bounds a native click on a located element before falling back to a DOM click
+const clickTimeout = 3 * time.Second
+
+// locatorSeq numbers the marker attributes put on located elements
+var locatorSeq atomic.Int64
+
+// Locator is a parsed locator string
+type Locator struct {
+	Kind  LocatorKind `json:"kind"`
+	Query string      `json:"query"`
+}
+
+// ParseLocator splits a locator string into its kind and query
+func ParseLocator(s string) Locator {
+	s = strings.TrimSpace(s)
+	for _, kind := range []LocatorKind{LocatorCSS, LocatorXPath, LocatorText, LocatorLabel, LocatorRole} {
+		if query, ok := strings.CutPrefix(s, string(kind)+"="); ok {
+			return Locator{Kind: kind, Query: strings.TrimSpace(query)}
+		}
+	}
+	if strings.HasPrefix(s, "//") || strings.HasPrefix(s, "(//") {
+		return Locator{Kind: LocatorXPath, Query: s}
+	}
+	return Locator{Kind: LocatorCSS, Query: s}
+}
+
+// String formats the locator back into its config form
+func (l Locator) String() string {
+	if l.Kind == LocatorCSS {
+		return l.Query
+	}
+	return string(l.Kind) + "=" + l.Query
+}
+
+// locatorEngineJS defines __crmLocate(kind, query) returning the matching elements
+const locatorEngineJS = `
+const __crmLocate = (() => {
+	const isVisible = el => !!(el.offsetWidth || el.offsetHeight || el.getClientRects().length);
+
+	// Split at top-level occurrences of the given characters
+	function splitTopLevel(sel, seps) {
+		const parts = [];
+		let depth = 0, quote = null, cur = '';
+		for (const ch of sel) {
+			if (quote) { cur += ch; if (ch === quote) quote = null; continue; }
+			if (ch === '"' || ch === "'") { quote = ch; cur += ch; continue; }
+			if (ch === '(' || ch === '[') depth++;
+			if (ch === ')' || ch === ']') depth--;
+			if (depth === 0 && seps.includes(ch)) { parts.push(cur); parts.push(ch); cur = ''; continue; }
+			cur += ch;
+		}
+		parts.push(cur);
+		return parts;
+	}
+
+	// Collapse whitespace and the spaces around combinators to single tokens,
+	// leaving quoted strings (e.g. :contains("A > B")) untouched
+	function normaliseCombinators(sel) {
+		let out = '', quote = null, space = false;
+		for (const ch of sel) {
+			if (quote) { out += ch; if (ch === quote) quote = null; continue; }
+			if (/\s/.test(ch)) { space = true; continue; }
+			if (ch === '>' || ch === '+' || ch === '~') { out += ch; space = false; continue; }
+			if (space && out !== '' && !'>+~'.includes(out[out.length - 1])) out += ' ';
+			space = false;
+			if (ch === '"' || ch === "'") quote = ch;
+			out += ch;
+		}
+		return out;
+	}
+
+	// Read a parenthesised argument starting at s[i] === '(' and return [arg, endIndex]
+	function readArg(s, i) {
+		let depth = 0, quote = null;
+		for (let j = i; j < s.length; j++) {
+			const ch = s[j];
+			if (quote) { if (ch === quote) quote = null; continue; }
+			if (ch === '"' || ch === "'") { quote = ch; continue; }
+			if (ch === '(') depth++;
+			if (ch === ')') { depth--; if (depth === 0) return [s.slice(i + 1, j), j]; }
+		}
+		return [s.slice(i + 1), s.length];
+	}
+
+	const unquote = s => s.trim().replace(/^(["'])(.*)\1$/, '$2');
+
+	// Parse a compound selector, pulling out top-level :contains / :not(:contains) / :first
+	function parseCompound(s) {
+		const c = { base: '', has: [], not: [], first: false };
+		let depth = 0, quote = null;
+		for (let i = 0; i < s.length; i++) {
+			const ch = s[i];
+			if (!quote && depth === 0) {
+				if (s.startsWith(':not(:contains(', i)) {
+					const [arg, end] = readArg(s, i + 4);
+					c.not.push(unquote(readArg(arg, 9)[0]));
+					i = end;
+					continue;
+				}
+				if (s.startsWith(':contains(', i)) {
+					const [arg, end] = readArg(s, i + 9);
+					c.has.push(unquote(arg));
+					i = end;
+					continue;
+				}
+				if (s.startsWith(':first', i) && !/[-\w]/.test(s[i + 6] || '')) {
+					c.first = true;
+					i += 5;
+					continue;
+				}
+			}
+			if (quote) { if (ch === quote) quote = null; }
+			else if (ch === '"' || ch === "'") quote = ch;
+			else if (ch === '(' || ch === '[') depth++;
+			else if (ch === ')' || ch === ']') depth--;
+			c.base += ch;
+		}
+		c.base = c.base.trim() || '*';
+		return c;
+	}
+
+	function matches(el, c) {
+		if (c.base !== '*' && !el.matches(c.base)) return false;
+		const text = el.textContent || '';
+		return c.has.every(t => text.includes(t)) && !c.not.some(t => text.includes(t));
+	}
+
+	function css(sel) {
+		let results = [];
+		for (const part of splitTopLevel(sel, [','])) {
+			if (part === ',' || !part.trim()) continue;
+			const tokens = splitTopLevel(normaliseCombinators(part.trim()), [' ', '>', '+', '~'])
+				.filter(t => t !== '');
+			let current = null;
+			let combinator = null;
+			let first = false;
+			for (const token of tokens) {
+				if ([' ', '>', '+', '~'].includes(token)) { combinator = token; continue; }
+				const c = parseCompound(token);
+				first = first || c.first;
+				let next = [];
+				if (current === null) {
+					next = Array.from(document.querySelectorAll(c.base)).filter(el => matches(el, c));
+				} else {
+					for (const el of current) {
+						let candidates = [];
+						if (combinator === '>') candidates = Array.from(el.children);
+						else if (combinator === '+') candidates = el.nextElementSibling ? [el.nextElementSibling] : [];
+						else if (combinator === '~') { for (let n = el.nextElementSibling; n; n = n.nextElementSibling) candidates.push(n); }
+						else candidates = Array.from(el.querySelectorAll(c.base));
+						next.push(...candidates.filter(e => matches(e, c)));
+					}
+				}
+				current = [...new Set(next)];
+				combinator = null;
+			}
+			results.push(...(first ? (current || []).slice(0, 1) : (current || [])));
+		}
+		return [...new Set(results)];
+	}
+
+	function xpath(q) {
+		const snapshot = document.evaluate(q, document, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+		const out = [];
+		for (let i = 0; i < snapshot.snapshotLength; i++) {
+			const node = snapshot.snapshotItem(i);
+			if (node.nodeType === Node.ELEMENT_NODE) out.push(node);
+		}
+		return out;
+	}
+
+	function text(q) {
+		return Array.from(document.body.querySelectorAll('*')).filter(el => {
+			if (['SCRIPT', 'STYLE', 'NOSCRIPT'].includes(el.tagName)) return false;
+			if (!(el.textContent || '').includes(q)) return false;
+			return !Array.from(el.children).some(child => (child.textContent || '').includes(q));
+		});
+	}
+
+	function label(q) {
+		const out = [];
+		for (const lbl of document.querySelectorAll('label')) {
+			if (!(lbl.textContent || '').includes(q)) continue;
+			const control = lbl.control ||
+				(lbl.htmlFor && document.getElementById(lbl.htmlFor)) ||
+				lbl.querySelector('input, select, textarea') ||
+				(lbl.parentElement && lbl.parentElement.querySelector('input, select, textarea'));
+			if (control) out.push(control);
+		}
+		for (const el of document.querySelectorAll('input, select, textarea')) {
+			const aria = el.getAttribute('aria-label') || '';
+			const placeholder = el.getAttribute('placeholder') || '';
+			if (aria.includes(q) || placeholder.includes(q)) out.push(el);
+		}
+		return [...new Set(out)];
+	}
+
+	const implicitRoles = {
+		button: 'button, input[type="button"], input[type="submit"], input[type="reset"]',
+		link: 'a[href]',
+		textbox: 'input:not([type]), input[type="text"], input[type="search"], input[type="email"], input[type="tel"], input[type="url"], input[type="password"], textarea',
+		checkbox: 'input[type="checkbox"]',
+		radio: 'input[type="radio"]',
+		combobox: 'select',
+		dialog: 'dialog',
+		heading: 'h1, h2, h3, h4, h5, h6',
+		row: 'tr',
+		cell: 'td',
+		img: 'img'
+	};
+
+	function accessibleName(el) {
+		const labelledBy = el.getAttribute('aria-labelledby');
+		if (labelledBy) {
+			const text = labelledBy.split(/\s+/).map(id => document.getElementById(id)).filter(Boolean).map(n => n.textContent).join(' ');
+			if (text.trim()) return text.trim();
+		}
+		return (el.getAttribute('aria-label') || (el.labels && el.labels[0] && el.labels[0].textContent) ||
+			el.textContent || el.value || el.getAttribute('alt') || el.getAttribute('title') ||
+			el.getAttribute('placeholder') || '').trim();
+	}
+
+	function role(q) {
+		const m = q.match(/^([\w-]+)\s*(?:\[\s*name\s*=\s*(["']?)(.*?)\2\s*\])?$/);
+		if (!m) return [];
+		const [, roleName, , name] = m;
+		let sel = '[role="' + roleName + '"]';
+		if (implicitRoles[roleName]) sel += ', ' + implicitRoles[roleName];
+		return Array.from(document.querySelectorAll(sel)).filter(el => {
+			const explicit = el.getAttribute('role');
+			if (explicit && explicit !== roleName) return false;
+			return name === undefined || accessibleName(el).includes(name);
+		});
+	}
+
+	return (kind, query) => {
+		try {
+			let found = [];
+			switch (kind) {
+				case 'xpath': found = xpath(query); break;
+				case 'text': found = text(query); break;
+				case 'label': found = label(query); break;
+				case 'role': found = role(query); break;
+				default: found = css(query);
+			}
+			// Prefer visible elements but keep hidden ones as a last resort
+			return found.filter(isVisible).concat(found.filter(el => !isVisible(el)));
+		} catch (e) {
+			return [];
+		}
+	};
+})();
+`
+
+// locatorScript wraps body with the locator engine in an IIFE
+func locatorScript(body string) string {
+	return "(() => {\n" + locatorEngineJS + "\n" + body + "\n})()"
+}
+
+// locatorArgs returns the JS literals for a locator's kind and query
+func locatorArgs(locator string) (string, string) {
+	l := ParseLocator(locator)
+	kind, _ := json.Marshal(string(l.Kind))
+	query, _ := json.Marshal(l.Query)
+	return string(kind), string(query)
+}
+
+// markLocator tags the first element matching locator with a unique attribute
+// and returns a CSS selector addressing exactly that element
+func markLocator(ctx context.Context, locator string) (string, error) {
+	id := locatorSeq.Add(1)
+	kind, query := locatorArgs(locator)
+
+	var found bool
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+			const el = __crmLocate(%s, %s)[0];
+			if (!el) return false;
+			el.setAttribute('data-crm-locator', '%d');
+			return true;
+		`, kind, query, id)), &found),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate locator %q: %w", locator, err)
+	}
+	if !found {
+		return "", fmt.Errorf("locator %q did not match any element", locator)
+	}
+	return fmt.Sprintf(`[data-crm-locator="%d"]`, id), nil
+}
+
+// clickLocator clicks the first element matching locator, falling back to a
+// DOM click when the element cannot be clicked natively (e.g. it is covered)
+func clickLocator(ctx context.Context, locator string) error {
+	sel, err := markLocator(ctx, locator)
+	if err != nil {
+		return err
+	}
+
+	clickCtx, cancel := context.WithTimeout(ctx, clickTimeout)
+	defer cancel()
+	if err := chromedp.Run(clickCtx, chromedp.Click(sel, chromedp.ByQuery)); err == nil {
+		return nil
+	}
+
+	var clicked bool
+	err = chromedp.Run(ctx,
+		chromedp.Evaluate(fmt.Sprintf(`
+			(() => {
+				const el = document.querySelector('%s');
+				if (!el) return false;
+				el.click();
+				return true;
+			})()
+		`, sel), &clicked),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to click %q: %w", locator, err)
+	}
+	if !clicked {
+		return fmt.Errorf("element for %q disappeared before click", locator)
+	}
+	return nil
+}
+
+// sendKeysLocator types text into the first element matching locator
+func sendKeysLocator(ctx context.Context, locator, text string) error {
+	sel, err := markLocator(ctx, locator)
+	if err != nil {
+		return err
+	}
+
+	keysCtx, cancel := context.WithTimeout(ctx, clickTimeout)
+	defer cancel()
+	if err := chromedp.Run(keysCtx, chromedp.SendKeys(sel, text, chromedp.ByQuery)); err != nil {
+		return fmt.Errorf("failed to type into %q: %w", locator, err)
+	}
+	return nil
+}
+
+// fillLocator sets the first form control matching locator. A select takes
+// the first option whose value or text equals one of values, a checkbox or
+// radio button is checked, and any other control is set to values[0]. Input
+// and change events are dispatched so React-controlled forms see the change.
+func fillLocator(ctx context.Context, locator string, values ...string) error {
+	kind, query := locatorArgs(locator)
+	accepted, _ := json.Marshal(values)
+
+	var problem string
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+			const el = __crmLocate(%s, %s)[0];
+			const values = %s;
+			if (!el) return 'no element';
+			const changed = () => {
+				el.dispatchEvent(new Event('input', {bubbles: true}));
+				el.dispatchEvent(new Event('change', {bubbles: true}));
+			};
+			if (el.tagName === 'SELECT') {
+				const option = Array.from(el.options).find(o => values.includes(o.value) || values.includes(o.textContent.trim()));
+				if (!option) return 'no option ' + values.join(' / ');
+				el.value = option.value;
+				changed();
+				return '';
+			}
+			if (el.type === 'checkbox' || el.type === 'radio') {
+				if (!el.checked) el.click();
+				return el.checked ? '' : 'could not check';
+			}
+			if (!('value' in el)) return 'not a form control';
+			// The prototype setter bypasses React's value tracking
+			const setter = Object.getOwnPropertyDescriptor(Object.getPrototypeOf(el), 'value');
+			if (setter && setter.set) {
+				setter.set.call(el, values[0]);
+			} else {
+				el.value = values[0];
+			}
+			changed();
+			return '';
+		`, kind, query, accepted)), &problem),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate locator %q: %w", locator, err)
+	}
+	if problem != "" {
+		return fmt.Errorf("could not fill %q: %s", locator, problem)
+	}
+	return nil
+}
+
+// textLocator returns the trimmed text of the first element matching locator
+func textLocator(ctx context.Context, locator string) (string, error) {
+	kind, query := locatorArgs(locator)
+
+	var text string
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+			const el = __crmLocate(%s, %s)[0];
+			return el ? (el.innerText || el.textContent || '').trim() : '';
+		`, kind, query)), &text),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate locator %q: %w", locator, err)
+	}
+	return text, nil
+}
+
+// locatorExists reports whether any of locators matches an element
+func locatorExists(ctx context.Context, locators []string) bool {
+	for _, locator := range locators {
+		kind, query := locatorArgs(locator)
+		var found bool
+		err := chromedp.Run(ctx,
+			chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+				return __crmLocate(%s, %s).length > 0;
+			`, kind, query)), &found),
+		)
+		if err == nil && found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestParseLocator(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Locator
+	}{
+		{`input[name="email"]`, Locator{LocatorCSS, `input[name="email"]`}},
+		{`  button:contains("ログイン")  `, Locator{LocatorCSS, `button:contains("ログイン")`}},
+		{`css= .modal > .close`, Locator{LocatorCSS, `.modal > .close`}},
+		{`//dt[text()="賃料"]`, Locator{LocatorXPath, `//dt[text()="賃料"]`}},
+		{`(//button)[2]`, Locator{LocatorXPath, `(//button)[2]`}},
+		{`xpath=id("main")`, Locator{LocatorXPath, `id("main")`}},
+		{`text=募集中`, Locator{LocatorText, `募集中`}},
+		{`text= 賃料 = 8万円 `, Locator{LocatorText, `賃料 = 8万円`}},
+		{`label=メールアドレス`, Locator{LocatorLabel, `メールアドレス`}},
+		{`role=button[name="検索"]`, Locator{LocatorRole, `button[name="検索"]`}},
+		// Unknown prefixes are CSS
+		{`data=foo`, Locator{LocatorCSS, `data=foo`}},
+		{`a[href="/text=x"]`, Locator{LocatorCSS, `a[href="/text=x"]`}},
+		{``, Locator{LocatorCSS, ``}},
+	}
+	for _, tt := range tests {
+		if got := ParseLocator(tt.in); got != tt.want {
+			t.Errorf("ParseLocator(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLocatorStringRoundTrip(t *testing.T) {
+	for _, s := range []string{`.close`, `xpath=//div`, `text=募集中`, `label=パスワード`, `role=button[name="OK"]`} {
+		if got := ParseLocator(s).String(); got != s {
+			t.Errorf("ParseLocator(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func TestLocatorCombinatorsInQuotes(t *testing.T) {
+	cfg := mockBrowserConfig(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html><body>
+			<ul><li><a id="plain">A  >  B</a></li></ul>
+			<ul><li><a id="spaced">x + y ~ z</a></li></ul>
+			<div><span id="child">子</span></div>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel, err := newBrowserContext(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if err := chromedp.Run(ctx, chromedp.Navigate(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		locator, want string
+	}{
+		// Combinators and spacing inside quotes are part of the text
+		{`li > a:contains("A  >  B")`, "plain"},
+		{`ul  li   a:contains('x + y ~ z')`, "spaced"},
+		// Outside quotes they still combine compounds
+		{`div >span:contains("子")`, "child"},
+	}
+	for _, tt := range tests {
+		var id string
+		kind, query := locatorArgs(tt.locator)
+		err := chromedp.Run(ctx, chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+			const el = __crmLocate(%s, %s)[0];
+			return el ? el.id : '';
+		`, kind, query)), &id))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.locator, err)
+		}
+		if id != tt.want {
+			t.Errorf("%s matched %q, want %q", tt.locator, id, tt.want)
+		}
+	}
+}
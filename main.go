@@ -1,101 +1,86 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
 func main() {
-	// Parse command line arguments
-	propertyName := flag.String("property", "", "Property name to search for")
-	headless := flag.Bool("headless", false, "Run in headless mode")
-	analyze := flag.Bool("analyze", false, "Run in analysis mode to inspect HTML structure")
-	updated := flag.Bool("updated", false, "Use updated scraper that works with actual ITANDI BB structure")
-	findLogin := flag.Bool("find-login", false, "Search for email/password login pages")
-	emailLogin := flag.Bool("email-login", false, "Use email/password login instead of phone verification")
-	analyzeSearch := flag.Bool("analyze-search", false, "Analyze the search flow")
-	detailedAnalysis := flag.Bool("detailed-analysis", false, "Detailed analysis of search results")
-	testModal := flag.Bool("test-modal", false, "Test modal advertisement handling")
-	flag.Parse()
+	runCLI(os.Args[1:])
+}
 
-	// Run analysis mode
-	if *analyze {
-		runAnalysis()
-		return
-	}
-
-	// Run updated scraper
-	if *updated {
-		runUpdatedScraper()
-		return
-	}
-
-	// Find login pages
-	if *findLogin {
-		findLoginPage()
-		return
-	}
-
-	// Email/password login
-	if *emailLogin {
-		runEmailLogin()
-		return
-	}
+// runConfirm implements "confirm": log in, search one property, save its
+// details and screenshots of every step
+func runConfirm(args []string) {
+	fs := newFlagSet("confirm", "[flags] <property name>", "Confirm one property on ITANDI BB and save its details and screenshots.")
+	var opts cliOptions
+	propertyName := fs.String("property", "", "Property name to search for (same as the argument)")
+	roomNumber := fs.String("room", "", "Room number to confirm, e.g. 302; only that room's listing is returned")
+	address := fs.String("address", "", "Address of the property, used to pick it among similarly named results")
+	company := fs.String("management-company", "", "Management company of the property, used to pick it among similarly named results")
+	opts.browserFlags(fs)
+	opts.sessionFlags(fs)
+	opts.scraperFlags(fs)
+	opts.resultFlags(fs)
+	opts.outputFlags(fs)
+	fs.Parse(args)
 
-	// Analyze search flow
-	if *analyzeSearch {
-		analyzeSearchFlow()
-		return
+	if fs.NArg() > 0 {
+		*propertyName = strings.Join(fs.Args(), " ")
 	}
-
-	// Detailed analysis
-	if *detailedAnalysis {
-		analyzeDetailedSearch()
-		return
-	}
-	
-	// Test modal handling
-	if *testModal {
-		testModalHandling()
-		return
-	}
-
 	if *propertyName == "" {
 		log.Println("No property name specified. Running in demo mode.")
 		*propertyName = "サンプル物件" // Default property name for testing
 	}
 
+	browserCfg, site, session := opts.browser(), opts.site(), opts.session()
+	out := opts.outputOptions()
+	notifier := opts.notifier()
+	history := opts.historyStore()
+	if history != nil {
+		defer history.Close()
+	}
+
 	// Create scraper instance
-	scraper, err := NewITANDIScraper(*headless)
+	scraper, err := NewITANDIScraperWithConfig(browserCfg, site, session)
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
 	defer scraper.Close()
+	scraper.UseSelectors(opts.selectorStore())
+	scraper.UseRetryPolicy(opts.retryPolicy())
+	scraper.UseDetailPages(opts.details)
 
-	// Step 1: Navigate to login page
-	log.Println("=== Step 1: Navigating to login page ===")
-	if err := scraper.NavigateToLogin(); err != nil {
-		log.Fatal("Failed to navigate:", err)
-	}
+	if session.ProfileDir != "" || session.File != "" {
+		// Step 1-2: Reuse the saved session, logging in only when it has expired
+		log.Println("=== Step 1-2: Restoring saved session ===")
+		if err := scraper.EnsureLoggedIn(); err != nil {
+			log.Fatal("Failed to login:", err)
+		}
+	} else {
+		// Step 1: Navigate to login page
+		log.Println("=== Step 1: Navigating to login page ===")
+		if err := scraper.NavigateToLogin(); err != nil {
+			log.Fatal("Failed to navigate:", err)
+		}
 
-	// Wait a bit for page to fully load
-	time.Sleep(2 * time.Second)
+		// Take screenshot for verification
+		if err := scraper.TakeScreenshot("step1_login_page.png"); err != nil {
+			log.Println("Warning: Failed to take screenshot:", err)
+		}
 
-	// Take screenshot for verification
-	if err := scraper.TakeScreenshot("step1_login_page.png"); err != nil {
-		log.Println("Warning: Failed to take screenshot:", err)
-	}
+		log.Println("Step 1 completed: Successfully accessed ITANDI login page")
 
-	log.Println("Step 1 completed: Successfully accessed ITANDI login page")
-	
-	// Step 2: Perform login
-	log.Println("\n=== Step 2: Logging in ===")
-	if err := scraper.Login(); err != nil {
-		log.Fatal("Failed to login:", err)
+		// Step 2: Perform login
+		log.Println("\n=== Step 2: Logging in ===")
+		if err := scraper.Login(); err != nil {
+			log.Fatal("Failed to login:", err)
+		}
 	}
 	
 	// Take screenshot after login
@@ -106,43 +91,55 @@ func main() {
 	log.Println("Step 2 completed: Successfully logged in to ITANDI BB")
 	
 	// Step 3: Search for property
-	log.Printf("\n=== Step 3: Searching for property '%s' ===\n", *propertyName)
-	if err := scraper.SearchProperty(*propertyName); err != nil {
-		log.Fatal("Failed to search property:", err)
-	}
+	log.Printf("\n=== Step 3: Searching for property '%s' %s ===\n", *propertyName, *roomNumber)
+	criteria := SearchCriteria{PropertyName: *propertyName, Address: *address, ManagementCompany: *company}
+	confirmation := BatchResult{BatchItem: BatchItem{PropertyName: *propertyName, RoomNumber: *roomNumber, Address: *address, ManagementCompany: *company}}
+	err = scraper.Search(criteria)
 	
-	// Take screenshot of search results
-	if err := scraper.TakeScreenshot("step3_search_results.png"); err != nil {
-		log.Println("Warning: Failed to take screenshot:", err)
-	}
+	// Take screenshot of search results under a name unique to this run,
+	// keeping the page as this run saw it even when the search failed
+	confirmation.Screenshot = scraper.captureRunScreenshot()
 	
-	log.Println("Step 3 completed: Property search executed")
+	var result *SearchResult
+	if err != nil {
+		log.Println("Failed to search property:", err)
+	} else {
+		log.Println("Step 3 completed: Property search executed")
 	
-	// Step 4: Get property details
-	log.Println("\n=== Step 4: Extracting property details ===")
-	details, err := scraper.GetPropertyDetails()
+		// Step 4: Get property details
+		log.Println("\n=== Step 4: Extracting property details ===")
+		result, err = scraper.GetRoomDetails(*roomNumber)
+		if err != nil {
+			log.Printf("Warning: Failed to get property details: %v\n", err)
+		}
+	}
+	confirmation.ConfirmedAt = time.Now()
 	if err != nil {
-		log.Printf("Warning: Failed to get property details: %v\n", err)
+		confirmation.setError(err)
+	} else {
+		confirmation.setResult(result)
+	}
+	// Failed confirmations are recorded and notified like batch rows
+	recordHistory(history, &confirmation, SourceCLI)
+	notifier.Notify(context.Background(), confirmation)
+
+	if out.Enabled() {
+		// Only the requested format goes to stdout; everything else is logged
+		if confirmation.Diff != nil {
+			log.Print(confirmation.Diff.Summary())
+		}
+		if err := out.Write([]BatchResult{confirmation}); err != nil {
+			log.Fatal("Failed to write results:", err)
+		}
 	} else {
-		// Print details in JSON format for easy parsing
-		jsonData, _ := json.MarshalIndent(details, "", "  ")
-		fmt.Printf("\nProperty Details (JSON):\n%s\n", jsonData)
-		
-		// Save to JSON file
-		jsonFileName := fmt.Sprintf("property_details_%s.json", time.Now().Format("20060102_150405"))
-		if err := os.WriteFile(jsonFileName, jsonData, 0644); err != nil {
-			log.Printf("Error saving JSON file: %v\n", err)
-		} else {
-			fmt.Printf("\nJSON saved to: %s\n", jsonFileName)
-		}
-		
-		// Also print in readable format
-		fmt.Println("\nProperty Details:")
-		for key, value := range details {
-			fmt.Printf("- %s: %s\n", key, value)
+		if confirmation.Diff != nil {
+			fmt.Printf("\n%s", confirmation.Diff.Summary())
+		}
+		if err == nil {
+			printPropertyDetails(result)
 		}
 	}
-	
+
 	// Take final screenshot
 	if err := scraper.TakeScreenshot("step4_property_details.png"); err != nil {
 		log.Println("Warning: Failed to take screenshot:", err)
@@ -151,8 +148,91 @@ func main() {
 	log.Println("\n=== All steps completed ===")
 	
 	// Keep browser open for a few seconds for visual confirmation if not headless
-	if !*headless {
+	if !browserCfg.Headless {
 		log.Println("Keeping browser open for 5 seconds...")
 		time.Sleep(5 * time.Second)
 	}
-}
\ No newline at end of file
+	
+	if confirmation.Status == BatchStatusError {
+		// os.Exit skips the deferred closes
+		scraper.Close()
+		if history != nil {
+			history.Close()
+		}
+		os.Exit(1)
+	}
+}
+
+// printPropertyDetails prints the result as JSON and in readable form and
+// saves the JSON to property_details_YYYYMMDD_HHMMSS.json
+func printPropertyDetails(result *SearchResult) {
+	jsonData, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Printf("\nProperty Details (JSON):\n%s\n", jsonData)
+
+	jsonFileName := fmt.Sprintf("property_details_%s.json", time.Now().Format("20060102_150405"))
+	if err := os.WriteFile(jsonFileName, jsonData, 0644); err != nil {
+		log.Printf("Error saving JSON file: %v\n", err)
+	} else {
+		fmt.Printf("\nJSON saved to: %s\n", jsonFileName)
+	}
+
+	printSearchResult(result)
+}
+
+// printSearchResult prints the listings of a search result in readable form
+func printSearchResult(result *SearchResult) {
+	fmt.Printf("\nSearch status: %s (%d listings)\n", result.Status, len(result.Listings))
+	if result.NoResultsMessage != "" {
+		fmt.Printf("- message: %s\n", result.NoResultsMessage)
+	}
+	if m := result.Match; m != nil {
+		fmt.Printf("Matched %q to %s (score %.2f)", m.Requested, m.Name, m.Score)
+		if m.Ambiguous {
+			fmt.Print(" - ambiguous, check the alternatives")
+		}
+		fmt.Println()
+		for _, c := range m.Alternatives {
+			fmt.Printf("- alternative: %s %s (score %.2f, %d listings)\n", c.Name, c.Address, c.Score, c.Listings)
+		}
+	}
+	switch {
+	case result.Status == SearchStatusAmbiguousMatch:
+		fmt.Println("Could not tell the requested building apart - all listings are shown")
+		if result.Room != "" {
+			fmt.Printf("Room %s: not checked\n", result.Room)
+		}
+	case result.Status == SearchStatusRoomNotListed:
+		fmt.Printf("Room %s: not listed", result.Room)
+		if len(result.OtherRooms) > 0 {
+			fmt.Printf(" (listed rooms: %s)", strings.Join(result.OtherRooms, ", "))
+		}
+		fmt.Println()
+	case result.Room != "" && result.HasResults():
+		fmt.Printf("Room %s: listed\n", result.Room)
+	}
+
+	for i, listing := range result.Listings {
+		fmt.Printf("\nListing %d:\n", i+1)
+		fmt.Printf("- name: %s\n", listing.Name)
+		if listing.RoomNumber != "" {
+			fmt.Printf("- room: %s\n", listing.RoomNumber)
+		}
+		fmt.Printf("- rent: %d円 (管理費 %d円)\n", listing.Rent, listing.ManagementFee)
+		fmt.Printf("- deposit/key money: %d円 / %d円\n", listing.Deposit, listing.KeyMoney)
+		fmt.Printf("- layout: %s, area: %.2f㎡, floor: %d\n", listing.Layout, listing.AreaSqm, listing.Floor)
+		fmt.Printf("- available: %s\n", listing.AvailableDate)
+		fmt.Printf("- status: %s\n", listing.Status)
+		if listing.ManagementCompany != "" {
+			fmt.Printf("- management company: %s\n", listing.ManagementCompany)
+		}
+		if d := listing.Detail; d != nil {
+			fmt.Printf("- contract: %s %s, renewal fee: %s\n", d.ContractType, d.ContractPeriod, d.RenewalFee)
+			fmt.Printf("- guarantor: %s, key exchange: %d円\n", d.GuarantorCompany, d.KeyExchangeFee)
+			fmt.Printf("- pets: %s, instruments: %s\n", d.PetCondition, d.InstrumentCondition)
+			fmt.Printf("- direction: %s, structure: %s, built: %s\n", d.Direction, d.Structure, d.BuiltDate)
+			if len(d.Facilities) > 0 {
+				fmt.Printf("- facilities: %s\n", strings.Join(d.Facilities, "、"))
+			}
+		}
+	}
+}
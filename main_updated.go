@@ -7,16 +7,11 @@ import (
 	"time"
 )
 
-func runUpdatedScraper() {
+func runUpdatedScraper(cfg BrowserConfig, site SiteConfig, companyName, propertyName string) {
 	log.Println("=== ITANDI BB Updated Scraper ===")
-	
-	// Use default values since flags are already parsed in main
-	companyName := "クレール" // Default company name
-	propertyName := "サンプル物件" // Default property name
-	headless := false // Default to visible mode
 
 	// Create scraper instance
-	scraper, err := NewITANDIScraperUpdated(headless)
+	scraper, err := NewITANDIScraperUpdatedWithConfig(cfg, site)
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
@@ -115,7 +110,7 @@ func runUpdatedScraper() {
 	log.Println("requires manual intervention with actual phone calls.")
 
 	// Keep browser open for manual inspection if not headless
-	if !headless {
+	if !cfg.Headless {
 		log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
 		time.Sleep(30 * time.Second)
 	}
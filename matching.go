@@ -0,0 +1,369 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// minMatchScore is the score below which the best building is only a weak match
+	minMatchScore = 0.7
+
+	// ambiguityMargin is how close to the best score another building may
+	// come before the match is ambiguous
+	ambiguityMargin = 0.1
+)
+
+// MatchTarget is the property a search is expected to find. Address and
+// ManagementCompany are optional and only help rank the result cards.
+type MatchTarget struct {
+	Name              string
+	Address           string
+	ManagementCompany string
+}
+
+// NameMatch は検索結果の建物と依頼された物件名の照合結果
+type NameMatch struct {
+	Requested string  `json:"requested"`
+	Query     string  `json:"query"` // the name as typed into the search field
+	Name      string  `json:"name"`
+	Address   string  `json:"address,omitempty"`
+	Score     float64 `json:"score"`
+
+	// Ambiguous is set when the best building scores below minMatchScore or
+	// another building scores within ambiguityMargin of it
+	Ambiguous bool `json:"ambiguous"`
+
+	// Alternatives are the other buildings in the results worth checking
+	Alternatives []MatchCandidate `json:"alternatives,omitempty"`
+}
+
+// MatchCandidate is one building of the search results and its score
+type MatchCandidate struct {
+	Name     string  `json:"name"`
+	Address  string  `json:"address,omitempty"`
+	Score    float64 `json:"score"`
+	Listings int     `json:"listings"`
+}
+
+var (
+	// generationPattern finds 第2 anywhere in a folded name
+	generationPattern = regexp.MustCompile(`第\s*(\d+)`)
+
+	// trailingGenerationPattern finds the 2 of "クレール長居2" or "クレール長居 2",
+	// leaving numbers that are part of a Latin name ("Room21") alone
+	trailingGenerationPattern = regexp.MustCompile(`([^\x00-\x7f])\s*(\d{1,2})$`)
+
+	// wingPattern finds a trailing 棟 name such as "A棟", "東棟" or "2号棟"
+	wingPattern = regexp.MustCompile(`(?i)\s*([a-z]|\d+号?|[東西南北中本新別])棟$`)
+
+	// addressUnitPattern finds the 丁目/番地/号 separators of a folded address
+	addressUnitPattern = regexp.MustCompile(`丁目|番地|番|号|ノ`)
+
+	// companyFormPattern finds legal forms written around a company name
+	companyFormPattern = regexp.MustCompile(`株式会社|有限会社|合同会社|\(株\)|\(有\)|\(同\)`)
+)
+
+// romanNumerals maps the Roman numeral characters to their values. NFKC
+// would turn Ⅱ into the Latin letters "II", which read as part of the name.
+var romanNumerals = map[rune]int{
+	'Ⅰ': 1, 'Ⅱ': 2, 'Ⅲ': 3, 'Ⅳ': 4, 'Ⅴ': 5, 'Ⅵ': 6, 'Ⅶ': 7, 'Ⅷ': 8, 'Ⅸ': 9, 'Ⅹ': 10, 'Ⅺ': 11, 'Ⅻ': 12,
+	'ⅰ': 1, 'ⅱ': 2, 'ⅲ': 3, 'ⅳ': 4, 'ⅴ': 5, 'ⅵ': 6, 'ⅶ': 7, 'ⅷ': 8, 'ⅸ': 9, 'ⅹ': 10, 'ⅺ': 11, 'ⅻ': 12,
+}
+
+// foldText reduces the spelling differences between the CRM and ITANDI BB:
+// full/half-width forms (NFKC), hiragana to katakana, hyphens written as a
+// katakana long vowel, and letter case. Roman numeral characters become
+// digits, separated by a space so "長居Ⅱ" still reads as 長居 2.
+func foldText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if n, ok := romanNumerals[r]; ok {
+			b.WriteString(" " + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	s = norm.NFKC.String(b.String())
+
+	b.Reset()
+	var prev rune
+	for _, r := range s {
+		switch {
+		case r >= 'ぁ' && r <= 'ゖ':
+			r += 'ァ' - 'ぁ'
+		case strings.ContainsRune("-‐‑–—―−", r) && unicode.In(prev, unicode.Katakana):
+			r = 'ー'
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// propertyName is a folded property name split into the parts compared
+// separately: the base name, its generation (第2, Ⅱ) and its 棟
+type propertyName struct {
+	base       string
+	generation int
+	wing       string
+}
+
+// parsePropertyName folds name and splits off its generation and 棟
+func parsePropertyName(name string) propertyName {
+	s := foldText(name)
+	var p propertyName
+
+	if m := wingPattern.FindStringSubmatchIndex(s); m != nil {
+		p.wing = strings.TrimSuffix(s[m[2]:m[3]], "号")
+		s = s[:m[0]]
+	}
+	if m := generationPattern.FindStringSubmatchIndex(s); m != nil {
+		p.generation, _ = strconv.Atoi(s[m[2]:m[3]])
+		s = s[:m[0]] + s[m[1]:]
+	} else if m := trailingGenerationPattern.FindStringSubmatchIndex(s); m != nil {
+		p.generation, _ = strconv.Atoi(s[m[4]:m[5]])
+		s = s[:m[3]]
+	}
+	p.base = nameKey(s)
+	return p
+}
+
+// nameKey drops the spaces and punctuation that names are written with or without
+func nameKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune("・･.,'’\"“”()（）「」[]【】", r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// searchQuery is the name typed into the search field. It is folded and
+// leaves out the generation and 棟, which ITANDI BB writes in many ways; the
+// scorer tells the generations and 棟 apart in the results instead.
+func searchQuery(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if _, ok := romanNumerals[r]; !ok {
+			b.WriteRune(r)
+		}
+	}
+	s := strings.Join(strings.Fields(norm.NFKC.String(b.String())), " ")
+	s = wingPattern.ReplaceAllString(s, "")
+	s = strings.TrimSpace(generationPattern.ReplaceAllString(s, ""))
+	if s == "" {
+		return strings.TrimSpace(name)
+	}
+	return s
+}
+
+// textSimilarity scores two folded strings from 0 to 1: 1 when equal,
+// 0.5 to 0.8 when one contains the other (クレール長居公園 is usually another
+// building than クレール長居, so containment alone is a weak match unless
+// little is left over), otherwise the Dice coefficient of their character
+// bigrams
+func textSimilarity(a, b string) float64 {
+	switch {
+	case a == b:
+		return 1
+	case a == "" || b == "":
+		return 0
+	}
+	short, long := []rune(a), []rune(b)
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if strings.Contains(string(long), string(short)) {
+		return 0.5 + 0.3*float64(len(short))/float64(len(long))
+	}
+
+	bigrams := func(r []rune) map[string]int {
+		m := make(map[string]int)
+		for i := 0; i+1 < len(r); i++ {
+			m[string(r[i:i+2])]++
+		}
+		return m
+	}
+	ma, mb := bigrams(short), bigrams(long)
+	shared, total := 0, 0
+	for g, n := range ma {
+		shared += min(n, mb[g])
+		total += n
+	}
+	for _, n := range mb {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+// nameScore compares two property names, discounting a different generation
+// or 棟. A generation on one side only (第2 requested, the card has none) is
+// another building as much as a different one, so even an equal base name
+// stays below minMatchScore.
+func nameScore(a, b propertyName) float64 {
+	score := textSimilarity(a.base, b.base)
+	switch {
+	case a.generation == b.generation:
+	case a.generation > 0 && b.generation > 0:
+		score *= 0.5
+	default:
+		score *= 0.6
+	}
+	switch {
+	case a.wing == b.wing:
+	case a.wing != "" && b.wing != "":
+		score *= 0.7
+	default:
+		score *= 0.95
+	}
+	return score
+}
+
+// addressKey folds an address and writes 1丁目2番3号 as 1-2-3
+func addressKey(address string) string {
+	s := nameKey(foldText(address))
+	s = addressUnitPattern.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+// companyKey folds a company name and drops its legal form
+func companyKey(company string) string {
+	return nameKey(companyFormPattern.ReplaceAllString(foldText(company), ""))
+}
+
+// partialSimilarity is 1 when one string contains the other, as a CRM
+// address often has the 番地 the card leaves out, otherwise textSimilarity
+func partialSimilarity(a, b string) float64 {
+	if a != "" && b != "" && (strings.Contains(a, b) || strings.Contains(b, a)) {
+		return 1
+	}
+	return textSimilarity(a, b)
+}
+
+// matchScore scores a listing against the target. The name counts fully,
+// the address half and the management company less, each only when both
+// sides have it.
+func matchScore(target MatchTarget, l PropertyListing) float64 {
+	sum := nameScore(parsePropertyName(target.Name), parsePropertyName(l.Name))
+	weight := 1.0
+	if a, b := addressKey(target.Address), addressKey(l.Address); a != "" && b != "" {
+		sum += 0.5 * partialSimilarity(a, b)
+		weight += 0.5
+	}
+	if a, b := companyKey(target.ManagementCompany), companyKey(l.ManagementCompany); a != "" && b != "" {
+		sum += 0.3 * partialSimilarity(a, b)
+		weight += 0.3
+	}
+	return sum / weight
+}
+
+// rankBuildings groups the listings by building and scores each against
+// target, best first. Buildings of the same name at different addresses or
+// with different management companies are ranked separately.
+func rankBuildings(target MatchTarget, listings []PropertyListing) ([]MatchCandidate, [][]PropertyListing) {
+	var (
+		candidates []MatchCandidate
+		groups     [][]PropertyListing
+		index      = make(map[string]int)
+	)
+	for _, l := range listings {
+		key := nameKey(foldText(l.Name)) + "|" + addressKey(l.Address) + "|" + companyKey(l.ManagementCompany)
+		i, ok := index[key]
+		if !ok {
+			i = len(candidates)
+			index[key] = i
+			candidates = append(candidates, MatchCandidate{Name: l.Name, Address: l.Address})
+			groups = append(groups, nil)
+		}
+		candidates[i].Score = max(candidates[i].Score, matchScore(target, l))
+		candidates[i].Listings++
+		groups[i] = append(groups[i], l)
+	}
+
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	// A stable sort keeps ITANDI BB's order between equal scores
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(candidates[b].Score, candidates[a].Score)
+	})
+
+	rankedCandidates := make([]MatchCandidate, len(order))
+	rankedGroups := make([][]PropertyListing, len(order))
+	for i, j := range order {
+		candidates[j].Score = roundScore(candidates[j].Score)
+		rankedCandidates[i], rankedGroups[i] = candidates[j], groups[j]
+	}
+	return rankedCandidates, rankedGroups
+}
+
+// roundScore keeps two decimals so scores read well in JSON and logs
+func roundScore(score float64) float64 {
+	return float64(int(score*100+0.5)) / 100
+}
+
+// SelectBuilding narrows the listings to the building that best matches
+// target instead of trusting the first card, and records the match with
+// the other plausible buildings. When the match is ambiguous the listings
+// are kept and the status becomes SearchStatusAmbiguousMatch, so a weak or
+// tied match is never reported as found. An empty target name leaves the
+// result unchanged.
+func (r *SearchResult) SelectBuilding(target MatchTarget) {
+	if strings.TrimSpace(target.Name) == "" || r.Status != SearchStatusFound || len(r.Listings) == 0 {
+		return
+	}
+
+	candidates, groups := rankBuildings(target, r.Listings)
+	best := candidates[0]
+	match := &NameMatch{
+		Requested: target.Name,
+		Query:     searchQuery(target.Name),
+		Name:      best.Name,
+		Address:   best.Address,
+		Score:     best.Score,
+		Ambiguous: best.Score < minMatchScore,
+	}
+	for _, c := range candidates[1:] {
+		near := roundScore(best.Score-c.Score) < ambiguityMargin
+		if near {
+			match.Ambiguous = true
+		}
+		if near || c.Score >= minMatchScore {
+			match.Alternatives = append(match.Alternatives, c)
+		}
+	}
+
+	r.Match = match
+	if match.Ambiguous {
+		r.Status = SearchStatusAmbiguousMatch
+		return
+	}
+	r.Listings = groups[0]
+}
+
+// String describes the match for logs, e.g. "クレール長居Ⅱ (0.95)"
+func (m *NameMatch) String() string {
+	s := fmt.Sprintf("%s (%.2f)", m.Name, m.Score)
+	if len(m.Alternatives) == 0 {
+		return s
+	}
+	alternatives := make([]string, len(m.Alternatives))
+	for i, c := range m.Alternatives {
+		alternatives[i] = fmt.Sprintf("%s (%.2f)", c.Name, c.Score)
+	}
+	return s + ", alternatives: " + strings.Join(alternatives, ", ")
+}
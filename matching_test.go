@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParsePropertyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want propertyName
+	}{
+		{"クレール長居", propertyName{base: "クレール長居"}},
+		{"くれーる長居", propertyName{base: "クレール長居"}},
+		{"ｸﾚｰﾙ長居", propertyName{base: "クレール長居"}},
+		{"クレール　長居", propertyName{base: "クレール長居"}},
+		{"クレ－ル長居", propertyName{base: "クレール長居"}},
+		{"第２クレール長居", propertyName{base: "クレール長居", generation: 2}},
+		{"クレール長居Ⅱ", propertyName{base: "クレール長居", generation: 2}},
+		{"クレール長居 2", propertyName{base: "クレール長居", generation: 2}},
+		{"クレール長居 A棟", propertyName{base: "クレール長居", wing: "a"}},
+		{"クレール長居Ⅱ 東棟", propertyName{base: "クレール長居", generation: 2, wing: "東"}},
+		{"クレール長居 2号棟", propertyName{base: "クレール長居", wing: "2"}},
+		{"Ｒｏｏｍ２１", propertyName{base: "room21"}},
+	}
+	for _, tt := range tests {
+		if got := parsePropertyName(tt.name); got != tt.want {
+			t.Errorf("parsePropertyName(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"クレール長居", "クレール長居"},
+		{"ｸﾚｰﾙ長居", "クレール長居"},
+		{"クレール　長居", "クレール 長居"},
+		{"第２クレール長居", "クレール長居"},
+		{"クレール長居Ⅱ", "クレール長居"},
+		{"クレール長居 A棟", "クレール長居"},
+		{"第2", "第2"},
+	}
+	for _, tt := range tests {
+		if got := searchQuery(tt.name); got != tt.want {
+			t.Errorf("searchQuery(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	if got := textSimilarity("クレール長居", "クレール長居"); got != 1 {
+		t.Errorf("equal = %v, want 1", got)
+	}
+	if got := roundScore(textSimilarity("クレール", "クレール住吉")); got != 0.7 {
+		t.Errorf("contained = %v, want 0.7", got)
+	}
+	if got := textSimilarity("クレール長居", "クレール住吉"); got != 0.6 {
+		t.Errorf("different = %v, want 0.6", got)
+	}
+	if got := textSimilarity("クレール長居", ""); got != 0 {
+		t.Errorf("empty = %v, want 0", got)
+	}
+}
+
+func TestAddressAndCompanyKeys(t *testing.T) {
+	if got := addressKey("大阪府大阪市住吉区長居１丁目２番３号"); got != "大阪府大阪市住吉区長居1-2-3" {
+		t.Errorf("addressKey = %q", got)
+	}
+	if got := addressKey("大阪市住吉区長居1-2-3"); got != "大阪市住吉区長居1-2-3" {
+		t.Errorf("addressKey = %q", got)
+	}
+	for _, company := range []string{"株式会社Room", "㈱ROOM", "Room（株）"} {
+		if got := companyKey(company); got != "room" {
+			t.Errorf("companyKey(%q) = %q, want room", company, got)
+		}
+	}
+}
+
+// matchListings are cards of a search for "クレール長居"
+func matchListings() []PropertyListing {
+	return []PropertyListing{
+		{Name: "クレール長居", RoomNumber: "101", Address: "大阪府大阪市住吉区長居1丁目2-3"},
+		{Name: "クレール長居Ⅱ", RoomNumber: "201", Address: "大阪府大阪市住吉区長居2丁目5-8"},
+		{Name: "クレール長居Ⅱ", RoomNumber: "305", Address: "大阪府大阪市住吉区長居2丁目5-8"},
+		{Name: "クレール長居公園", RoomNumber: "102", Address: "大阪府大阪市東住吉区長居公園1-1"},
+	}
+}
+
+func TestSelectBuildingPicksGeneration(t *testing.T) {
+	r := &SearchResult{Status: SearchStatusFound, Listings: matchListings()}
+	r.SelectBuilding(MatchTarget{Name: "第２クレール長居"})
+
+	m := r.Match
+	if m == nil || m.Name != "クレール長居Ⅱ" || m.Score != 1 || m.Ambiguous {
+		t.Fatalf("Match = %+v, want クレール長居Ⅱ with score 1", m)
+	}
+	if m.Query != "クレール長居" || m.Requested != "第２クレール長居" {
+		t.Errorf("query = %q, requested = %q", m.Query, m.Requested)
+	}
+
+	var rooms []string
+	for _, l := range r.Listings {
+		rooms = append(rooms, l.RoomNumber)
+	}
+	if !slices.Equal(rooms, []string{"201", "305"}) {
+		t.Errorf("rooms = %v, want 201 and 305", rooms)
+	}
+}
+
+func TestSelectBuildingPrefersExactName(t *testing.T) {
+	r := &SearchResult{Status: SearchStatusFound, Listings: matchListings()}
+	r.SelectBuilding(MatchTarget{Name: "くれーる長居"})
+
+	m := r.Match
+	if m.Name != "クレール長居" || m.Score != 1 || m.Ambiguous {
+		t.Fatalf("Match = %+v, want クレール長居 with score 1", m)
+	}
+	if len(r.Listings) != 1 || r.Listings[0].RoomNumber != "101" {
+		t.Errorf("listings = %+v", r.Listings)
+	}
+	// クレール長居公園 contains the name, so it stays a plausible alternative
+	if len(m.Alternatives) == 0 || m.Alternatives[0].Name != "クレール長居公園" {
+		t.Errorf("Alternatives = %+v", m.Alternatives)
+	}
+}
+
+func TestSelectBuildingAmbiguous(t *testing.T) {
+	// Neither クレール長居Ⅱ (another generation) nor クレール長居公園 (a longer
+	// name) is a confident match for an A棟 that no card names
+	r := &SearchResult{Status: SearchStatusFound, Listings: matchListings()[1:]}
+	r.SelectBuilding(MatchTarget{Name: "クレール長居 A棟"})
+
+	m := r.Match
+	if !m.Ambiguous || m.Score >= minMatchScore {
+		t.Fatalf("Match = %+v, want a weak, ambiguous match", m)
+	}
+	// Not reported as found and not narrowed to a guess
+	if r.Status != SearchStatusAmbiguousMatch || len(r.Listings) != 3 {
+		t.Errorf("status = %q, listings = %d, want ambiguous_match with all 3", r.Status, len(r.Listings))
+	}
+	if !errors.Is(r.Err(), ErrAmbiguousMatch) || ErrorCode(r.Err()) != "ambiguous_match" {
+		t.Errorf("Err() = %v, want ErrAmbiguousMatch", r.Err())
+	}
+	var res BatchResult
+	res.setResult(r)
+	if res.Status != BatchStatusAmbiguousMatch {
+		t.Errorf("batch status = %q, want %q", res.Status, BatchStatusAmbiguousMatch)
+	}
+}
+
+func TestNameScoreGenerationMismatch(t *testing.T) {
+	tests := []struct {
+		requested, card string
+	}{
+		{"第2クレール長居", "クレール長居"},
+		{"クレール長居", "クレール長居Ⅱ"},
+		{"クレール長居Ⅲ", "クレール長居Ⅱ"},
+		{"第２クレール長居 A棟", "クレール長居"},
+	}
+	for _, tt := range tests {
+		score := nameScore(parsePropertyName(tt.requested), parsePropertyName(tt.card))
+		if score >= minMatchScore {
+			t.Errorf("nameScore(%q, %q) = %.2f, want below %.2f", tt.requested, tt.card, score, minMatchScore)
+		}
+	}
+	if score := nameScore(parsePropertyName("第2クレール長居"), parsePropertyName("クレール長居Ⅱ")); score != 1 {
+		t.Errorf("same generation scored %.2f, want 1", score)
+	}
+}
+
+func TestSelectBuildingUsesAddress(t *testing.T) {
+	listings := []PropertyListing{
+		{Name: "クレール", RoomNumber: "101", Address: "大阪府大阪市住吉区長居1丁目2-3"},
+		{Name: "クレール", RoomNumber: "202", Address: "兵庫県神戸市中央区港島1-1"},
+	}
+	r := &SearchResult{Status: SearchStatusFound, Listings: listings}
+	r.SelectBuilding(MatchTarget{Name: "クレール", Address: "神戸市中央区港島１丁目１番"})
+
+	if r.Match.Address != "兵庫県神戸市中央区港島1-1" || r.Match.Ambiguous {
+		t.Errorf("Match = %+v, want the Kobe building", r.Match)
+	}
+	if len(r.Listings) != 1 || r.Listings[0].RoomNumber != "202" {
+		t.Errorf("listings = %+v", r.Listings)
+	}
+
+	// Without the address the two buildings cannot be told apart
+	r = &SearchResult{Status: SearchStatusFound, Listings: listings}
+	r.SelectBuilding(MatchTarget{Name: "クレール"})
+	if !r.Match.Ambiguous || len(r.Match.Alternatives) != 1 {
+		t.Errorf("Match = %+v, want ambiguous with one alternative", r.Match)
+	}
+	if r.Status != SearchStatusAmbiguousMatch || len(r.Listings) != 2 {
+		t.Errorf("status = %q, listings = %d, want ambiguous_match with both", r.Status, len(r.Listings))
+	}
+}
+
+func TestSelectBuildingUsesManagementCompany(t *testing.T) {
+	listings := []PropertyListing{
+		{Name: "メゾン長居", RoomNumber: "101", ManagementCompany: "株式会社Room"},
+		{Name: "メゾン長居", RoomNumber: "202", ManagementCompany: "長居不動産株式会社"},
+	}
+	r := &SearchResult{Status: SearchStatusFound, Listings: listings}
+	r.SelectBuilding(MatchTarget{Name: "メゾン長居", ManagementCompany: "長居不動産"})
+
+	if len(r.Listings) != 1 || r.Listings[0].RoomNumber != "202" {
+		t.Errorf("listings = %+v, want room 202", r.Listings)
+	}
+}
+
+func TestSelectBuildingWeakMatch(t *testing.T) {
+	r := &SearchResult{Status: SearchStatusFound, Listings: []PropertyListing{{Name: "サンライズ住吉", RoomNumber: "101"}}}
+	r.SelectBuilding(MatchTarget{Name: "クレール長居"})
+
+	if r.Match == nil || !r.Match.Ambiguous || r.Match.Score >= minMatchScore {
+		t.Errorf("Match = %+v, want a weak, ambiguous match", r.Match)
+	}
+	if r.Status != SearchStatusAmbiguousMatch || len(r.Listings) != 1 {
+		t.Errorf("status = %q, listings = %+v, want ambiguous_match with the card kept", r.Status, r.Listings)
+	}
+}
+
+func TestSelectBuildingWithoutName(t *testing.T) {
+	r := &SearchResult{Status: SearchStatusFound, Listings: matchListings()}
+	r.SelectBuilding(MatchTarget{})
+	if r.Match != nil || len(r.Listings) != 4 {
+		t.Errorf("match = %+v, listings = %d, want the result unchanged", r.Match, len(r.Listings))
+	}
+
+	r = &SearchResult{Status: SearchStatusNoResults}
+	r.SelectBuilding(MatchTarget{Name: "クレール長居"})
+	if r.Match != nil {
+		t.Errorf("Match = %+v on a result without listings", r.Match)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockFixtures are saved ITANDI pages served by the mock server
+//
+//go:embed testdata/itandi/*.html
+var mockFixtures embed.FS
+
+const (
+	mockEmail         = "agent@example.com"
+	mockPassword      = "correct-horse"
+	mockSessionCookie = "mock_itandi_session"
+)
+
+// mockITANDIServer is an offline stand-in for both itandi-accounts.com and
+// itandibb.com. Accounts pages are served at "/" and BB pages under "/top"
+// and "/rent_rooms", so one server URL works as both base URLs.
+type mockITANDIServer struct {
+	*httptest.Server
+
+	// PhoneVerification serves the company selection page instead of the email login form
+	PhoneVerification bool
+
+	// Modal shows the イタンジ売却査定 modal on the rent_rooms list page
+	Modal bool
+
+	// Properties are the building names that return results.html
+	Properties []string
+
+	// PagedProperty is the building whose 3 rooms are split over results_page1.html and results_page2.html
+	PagedProperty string
+
+	// LastPageMissing fails page 2 of the paged results, as when a page cannot be loaded
+	LastPageMissing bool
+
+	mu         sync.Mutex
+	lastSearch url.Values
+}
+
+// newMockITANDIServer starts a mock server that is closed when the test ends
+func newMockITANDIServer(t *testing.T) *mockITANDIServer {
+	t.Helper()
+
+	m := &mockITANDIServer{
+		Modal:         true,
+		Properties:    []string{"クレール住吉"},
+		PagedProperty: "クレール長居",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", m.handleLoginPage)
+	mux.HandleFunc("POST /login", m.handleLogin)
+	mux.HandleFunc("GET /top", m.requireSession(m.handleTop))
+	mux.HandleFunc("GET /rent_rooms/list", m.requireSession(m.handleRentRoomsList))
+	mux.HandleFunc("GET /rent_rooms/{id}", m.requireSession(m.handleRoomDetail))
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// SiteConfig points both the accounts site and ITANDI BB at the mock server
+func (m *mockITANDIServer) SiteConfig() SiteConfig {
+	site := DefaultSiteConfig()
+	site.AccountsURL = m.URL
+	site.BBURL = m.URL
+	return site
+}
+
+// serveFixture writes a fixture page
+func serveFixture(w http.ResponseWriter, name string, status int) {
+	data, err := mockFixtures.ReadFile("testdata/itandi/" + name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// requireSession redirects to the login page unless the session cookie is set
+func (m *mockITANDIServer) requireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie(mockSessionCookie); err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (m *mockITANDIServer) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	if m.PhoneVerification {
+		serveFixture(w, "company_select.html", http.StatusOK)
+		return
+	}
+	serveFixture(w, "login.html", http.StatusOK)
+}
+
+func (m *mockITANDIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("email") != mockEmail || r.FormValue("password") != mockPassword {
+		serveFixture(w, "login.html", http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: mockSessionCookie, Value: "1", Path: "/", HttpOnly: true})
+	http.Redirect(w, r, "/top", http.StatusSeeOther)
+}
+
+func (m *mockITANDIServer) handleTop(w http.ResponseWriter, r *http.Request) {
+	serveFixture(w, "top.html", http.StatusOK)
+}
+
+func (m *mockITANDIServer) handleRentRoomsList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := strings.TrimSpace(query.Get("name"))
+	if query.Has("name") {
+		m.mu.Lock()
+		m.lastSearch = query
+		m.mu.Unlock()
+	}
+
+	switch {
+	case r.URL.Query().Get("page") == "2" && m.LastPageMissing:
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	case r.URL.Query().Get("page") == "2":
+		serveFixture(w, "results_page2.html", http.StatusOK)
+	case name != "" && m.PagedProperty == name:
+		serveFixture(w, "results_page1.html", http.StatusOK)
+	case name == "" && hasCriteria(query):
+		// Any criteria search returns the paged building
+		serveFixture(w, "results_page1.html", http.StatusOK)
+	case name == "" && m.Modal:
+		serveFixture(w, "rent_rooms_list_modal.html", http.StatusOK)
+	case name == "":
+		serveFixture(w, "rent_rooms_list.html", http.StatusOK)
+	case m.hasProperty(name):
+		serveFixture(w, "results.html", http.StatusOK)
+	default:
+		serveFixture(w, "empty_results.html", http.StatusOK)
+	}
+}
+
+func (m *mockITANDIServer) handleRoomDetail(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != "10001" {
+		http.NotFound(w, r)
+		return
+	}
+	serveFixture(w, "room_detail.html", http.StatusOK)
+}
+
+// LastSearch returns the query of the last submitted search form
+func (m *mockITANDIServer) LastSearch() url.Values {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastSearch
+}
+
+// hasCriteria reports whether a search form submission set any criterion besides the name
+func hasCriteria(query url.Values) bool {
+	for key, values := range query {
+		if key != "name" && key != "page" && slices.ContainsFunc(values, func(v string) bool { return v != "" }) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProperty reports whether a search for name matches one of the mock properties
+func (m *mockITANDIServer) hasProperty(name string) bool {
+	for _, p := range m.Properties {
+		if strings.Contains(p, name) {
+			return true
+		}
+	}
+	return false
+}
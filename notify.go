@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Notification events, from most to least important. A result can raise
+// several; Notification.Event is the most important one.
+const (
+	EventError              = "error"
+	EventRoomNotListed      = "room_not_listed"
+	EventAmbiguousMatch     = "ambiguous_match"
+	EventNoLongerRecruiting = "no_longer_recruiting"
+	EventChanges            = "changes"
+	EventFound              = "found"
+	EventNoResults          = "no_results"
+)
+
+// notifyEvents lists the events in priority order with their Japanese labels
+var notifyEvents = []struct {
+	event string
+	label string
+}{
+	{EventError, "確認エラー"},
+	{EventRoomNotListed, "部屋の掲載なし"},
+	{EventAmbiguousMatch, "物件の照合要確認"},
+	{EventNoLongerRecruiting, "募集終了"},
+	{EventChanges, "募集状況の変更"},
+	{EventFound, "掲載あり"},
+	{EventNoResults, "該当なし"},
+}
+
+// notifyTimeout bounds a single delivery to a sink
+const notifyTimeout = 10 * time.Second
+
+// defaultNotifyEvents are sent when a sink does not list its own
+var defaultNotifyEvents = []string{EventError, EventChanges}
+
+// defaultNotifyTemplate renders the message body of every sink
+const defaultNotifyTemplate = `{{if eq .Event "error" -}}
+【{{.EventLabel}}】{{.PropertyName}}{{with .RoomNumber}} {{.}}{{end}}
+{{.ErrorCode}}: {{.Error}}
+{{- else if eq .Event "ambiguous_match" -}}
+【{{.EventLabel}}】{{.PropertyName}}{{with .RoomNumber}} {{.}}{{end}}
+建物を特定できませんでした。候補:
+{{range .Listings}}- {{.Name}} {{room .}} {{.Status}}
+{{end}}
+{{- else if .Changed -}}
+【{{.EventLabel}}】{{.Diff.Summary}}
+{{- else -}}
+【{{.EventLabel}}】{{.PropertyName}}{{with .RoomNumber}} {{.}}{{end}}
+{{range .Listings}}- {{room .}} {{yen .Rent}} {{.Status}}
+{{end}}
+{{- end}}
+確認日時: {{datetime .ConfirmedAt}}`
+
+// defaultNotifySubject is the email subject
+const defaultNotifySubject = `[ITANDI BB] {{.EventLabel}}: {{.PropertyName}}{{with .RoomNumber}} {{.}}{{end}}`
+
+// notifyFuncs are available in message templates
+var notifyFuncs = template.FuncMap{
+	"yen":      formatYen,
+	"room":     func(l PropertyListing) string { return ListingRef{Name: l.Name, RoomNumber: l.RoomNumber}.String() },
+	"datetime": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04") },
+}
+
+// NotifyConfig lists where confirmation results are sent
+type NotifyConfig struct {
+	Sinks []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig is one notification destination and the results it wants
+type SinkConfig struct {
+	// Name identifies the sink in logs; defaults to its type
+	Name string `json:"name"`
+
+	// Type is "webhook" (generic JSON), "slack" (incoming webhook) or "email"
+	Type string `json:"type"`
+
+	// URL is the endpoint of webhook and slack sinks
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+
+	SMTP *SMTPConfig `json:"smtp"`
+
+	// Events filters results by event; default error and changes
+	Events []string `json:"events"`
+
+	// Properties, when set, only passes results whose property name contains one of them
+	Properties []string `json:"properties"`
+
+	// Template and Subject override the message body and email subject (text/template)
+	Template string `json:"template"`
+	Subject  string `json:"subject"`
+}
+
+// SMTPConfig is the mail server of an email sink
+type SMTPConfig struct {
+	Addr     string `json:"addr"` // host:port
+	Username string `json:"username"`
+
+	// PasswordEnv names the environment variable holding the password
+	PasswordEnv string   `json:"password_env"`
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+}
+
+// Notification は通知1件分の内容で、テンプレートとwebhookのJSONに使う
+type Notification struct {
+	Event        string            `json:"event"`
+	EventLabel   string            `json:"event_label"`
+	Events       []string          `json:"events"`
+	Message      string            `json:"message"`
+	PropertyName string            `json:"property_name"`
+	RoomNumber   string            `json:"room_number,omitempty"`
+	Status       string            `json:"status"`
+	ErrorCode    string            `json:"error_code,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Listings     []PropertyListing `json:"listings,omitempty"`
+
+	// MatchScore and AmbiguousMatch tell how surely the listings are the
+	// requested building; see NameMatch
+	MatchScore     *float64 `json:"match_score,omitempty"`
+	AmbiguousMatch bool     `json:"ambiguous_match"`
+
+	Diff        *ListingDiff `json:"diff,omitempty"`
+	Changed     bool         `json:"changed"`
+	ConfirmedAt time.Time    `json:"confirmed_at"`
+}
+
+// Sink delivers a rendered notification
+type Sink interface {
+	Send(ctx context.Context, n Notification, subject string) error
+}
+
+// Notifier sends confirmation results to the configured sinks
+type Notifier struct {
+	routes []notifyRoute
+}
+
+// notifyRoute is a sink with its filter and templates
+type notifyRoute struct {
+	name       string
+	sink       Sink
+	events     []string
+	properties []string
+	body       *template.Template
+	subject    *template.Template
+}
+
+// LoadNotifyConfig reads a JSON notification config; NewNotifier validates it
+func LoadNotifyConfig(path string) (NotifyConfig, error) {
+	var cfg NotifyConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read notify config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse notify config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// NewNotifier validates the sinks and parses their templates
+func NewNotifier(cfg NotifyConfig) (*Notifier, error) {
+	n := &Notifier{}
+	for i, sc := range cfg.Sinks {
+		route, err := newNotifyRoute(sc)
+		if err != nil {
+			return nil, fmt.Errorf("sink %d: %w", i+1, err)
+		}
+		n.routes = append(n.routes, route)
+	}
+	return n, nil
+}
+
+// newNotifyRoute builds the sink described by sc
+func newNotifyRoute(sc SinkConfig) (notifyRoute, error) {
+	route := notifyRoute{name: sc.Name, events: sc.Events, properties: sc.Properties}
+	if route.name == "" {
+		route.name = sc.Type
+	}
+	if len(route.events) == 0 {
+		route.events = defaultNotifyEvents
+	}
+	for _, e := range route.events {
+		if eventLabel(e) == "" {
+			return route, fmt.Errorf("unknown event %q", e)
+		}
+	}
+
+	switch sc.Type {
+	case "webhook", "slack":
+		if sc.URL == "" {
+			return route, fmt.Errorf("%s sink needs a url", sc.Type)
+		}
+		route.sink = &webhookSink{url: sc.URL, headers: sc.Headers, slack: sc.Type == "slack", client: &http.Client{}}
+	case "email":
+		if sc.SMTP == nil || sc.SMTP.Addr == "" || sc.SMTP.From == "" || len(sc.SMTP.To) == 0 {
+			return route, errors.New("email sink needs smtp addr, from and to")
+		}
+		route.sink = &emailSink{cfg: *sc.SMTP}
+	default:
+		return route, fmt.Errorf("unknown sink type %q", sc.Type)
+	}
+
+	var err error
+	body := orDefault(sc.Template, defaultNotifyTemplate)
+	if route.body, err = template.New("body").Funcs(notifyFuncs).Parse(body); err != nil {
+		return route, fmt.Errorf("invalid template: %w", err)
+	}
+	subject := orDefault(sc.Subject, defaultNotifySubject)
+	if route.subject, err = template.New("subject").Funcs(notifyFuncs).Parse(subject); err != nil {
+		return route, fmt.Errorf("invalid subject: %w", err)
+	}
+	return route, nil
+}
+
+// orDefault returns s, or fallback when s is empty
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// Notify sends res to every sink whose filter it passes. Failed deliveries
+// are logged and returned together; they never stop the confirmation.
+func (n *Notifier) Notify(ctx context.Context, res BatchResult) error {
+	if n == nil {
+		return nil
+	}
+	base := newNotification(res)
+
+	var errs []error
+	for _, route := range n.routes {
+		if !route.matches(base) {
+			continue
+		}
+		note := base
+		var body, subject bytes.Buffer
+		if err := route.body.Execute(&body, note); err != nil {
+			errs = append(errs, fmt.Errorf("%s: template: %w", route.name, err))
+			continue
+		}
+		if err := route.subject.Execute(&subject, note); err != nil {
+			errs = append(errs, fmt.Errorf("%s: subject: %w", route.name, err))
+			continue
+		}
+		note.Message = strings.TrimSpace(body.String())
+
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := route.sink.Send(sendCtx, note, strings.TrimSpace(subject.String()))
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.name, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		log.Printf("Warning: Failed to send notification for %s: %v\n", res.PropertyName, err)
+	}
+	return err
+}
+
+// newNotification collects the events raised by res
+func newNotification(res BatchResult) Notification {
+	note := Notification{
+		PropertyName: res.PropertyName,
+		RoomNumber:   res.RoomNumber,
+		Status:       res.Status,
+		ErrorCode:    res.ErrorCode,
+		Error:        res.Error,
+		Diff:         res.Diff,
+		Changed:      res.Diff != nil && res.Diff.HasChanges(),
+		ConfirmedAt:  res.ConfirmedAt,
+	}
+	if res.Result != nil {
+		note.Listings = res.Result.Listings
+		if m := res.Result.Match; m != nil {
+			note.MatchScore = &m.Score
+			note.AmbiguousMatch = m.Ambiguous
+		}
+	}
+
+	switch res.Status {
+	case BatchStatusError:
+		note.Events = append(note.Events, EventError)
+	case BatchStatusFound:
+		note.Events = append(note.Events, EventFound)
+	case BatchStatusNoResults:
+		note.Events = append(note.Events, EventNoResults)
+	case BatchStatusRoomNotListed:
+		note.Events = append(note.Events, EventRoomNotListed)
+	case BatchStatusAmbiguousMatch:
+		note.Events = append(note.Events, EventAmbiguousMatch)
+	}
+	if note.Changed {
+		note.Events = append(note.Events, EventChanges)
+		if len(res.Diff.NoLongerRecruiting) > 0 {
+			note.Events = append(note.Events, EventNoLongerRecruiting)
+		}
+	}
+
+	for _, e := range notifyEvents {
+		if slices.Contains(note.Events, e.event) {
+			note.Event, note.EventLabel = e.event, e.label
+			break
+		}
+	}
+	return note
+}
+
+// matches reports whether the route wants the notification
+func (r notifyRoute) matches(note Notification) bool {
+	if !slices.ContainsFunc(r.events, func(e string) bool { return slices.Contains(note.Events, e) }) {
+		return false
+	}
+	if len(r.properties) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(r.properties, func(p string) bool { return strings.Contains(note.PropertyName, p) })
+}
+
+// eventLabel returns the Japanese label of an event, or "" for unknown events
+func eventLabel(event string) string {
+	for _, e := range notifyEvents {
+		if e.event == event {
+			return e.label
+		}
+	}
+	return ""
+}
+
+// webhookSink POSTs the notification as JSON, or as {"text": ...} for Slack
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	slack   bool
+	client  *http.Client
+}
+
+func (s *webhookSink) Send(ctx context.Context, n Notification, subject string) error {
+	var payload any = n
+	if s.slack {
+		payload = map[string]string{"text": n.Message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// emailSink sends the notification as a plain-text UTF-8 mail
+type emailSink struct {
+	cfg SMTPConfig
+}
+
+func (s *emailSink) Send(ctx context.Context, n Notification, subject string) error {
+	host, _, err := net.SplitHostPort(s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp addr %q: %w", s.cfg.Addr, err)
+	}
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, os.Getenv(s.cfg.PasswordEnv), host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// net/smtp has no context support; run it aside so ctx still bounds the wait
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.cfg.Addr, auth, s.cfg.From, s.cfg.To, msg.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("smtp %s: %w", s.cfg.Addr, ctx.Err())
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// changedResult is a confirmation in which room 302 stopped recruiting
+func changedResult(name string) BatchResult {
+	day := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	res := historyResult(name, day.AddDate(0, 0, 1), [2]string{"302", "申込あり"})
+	diff := DiffRuns(
+		ConfirmationRun{PropertyName: name, ConfirmedAt: day, Listings: []PropertyListing{{Name: name, RoomNumber: "302", Status: "募集中", Rent: 77000}}},
+		ConfirmationRun{PropertyName: name, ConfirmedAt: res.ConfirmedAt, Listings: res.Result.Listings},
+	)
+	res.Diff = &diff
+	return res
+}
+
+func TestNotifierWebhookAndSlack(t *testing.T) {
+	received := make(map[string][]map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s: invalid JSON: %v", r.URL.Path, err)
+		}
+		if r.URL.Path == "/webhook" && r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("webhook header missing")
+		}
+		received[r.URL.Path] = append(received[r.URL.Path], body)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewNotifier(NotifyConfig{Sinks: []SinkConfig{
+		{Type: "webhook", URL: srv.URL + "/webhook", Headers: map[string]string{"Authorization": "Bearer secret"}},
+		{Type: "slack", URL: srv.URL + "/slack", Events: []string{EventNoLongerRecruiting}, Properties: []string{"クレール"}},
+		{Type: "slack", URL: srv.URL + "/custom", Events: []string{EventFound}, Template: "{{.PropertyName}}: {{len .Listings}}室"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Notify(context.Background(), changedResult("クレール住吉")); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	// Filtered out of the Slack sink by property name
+	if err := notifier.Notify(context.Background(), changedResult("サンプル物件")); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if got := received["/webhook"]; len(got) != 2 || got[0]["event"] != EventNoLongerRecruiting || got[0]["property_name"] != "クレール住吉" {
+		t.Errorf("webhook payloads = %v", got)
+	}
+	slack := received["/slack"]
+	if len(slack) != 1 {
+		t.Fatalf("slack payloads = %v", slack)
+	}
+	text, _ := slack[0]["text"].(string)
+	for _, want := range []string{"【募集終了】クレール住吉", "募集終了: 302号室（募集中 → 申込あり）", "確認日時: 2026-04-02 09:00"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("slack text missing %q:\n%s", want, text)
+		}
+	}
+	if custom := received["/custom"]; len(custom) != 2 || custom[0]["text"] != "クレール住吉: 1室" {
+		t.Errorf("custom payloads = %v", custom)
+	}
+}
+
+func TestNotifierAmbiguousMatch(t *testing.T) {
+	var received []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		received = append(received, body)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewNotifier(NotifyConfig{Sinks: []SinkConfig{
+		{Type: "webhook", URL: srv.URL, Events: []string{EventAmbiguousMatch}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &SearchResult{Status: SearchStatusFound, Listings: []PropertyListing{
+		{Name: "クレール長居Ⅱ", RoomNumber: "201", Status: "募集中"},
+		{Name: "クレール長居公園", RoomNumber: "102", Status: "募集中"},
+	}}
+	r.SelectBuilding(MatchTarget{Name: "クレール長居 A棟"})
+	res := BatchResult{BatchItem: BatchItem{PropertyName: "クレール長居 A棟"}, ConfirmedAt: time.Now()}
+	res.setResult(r)
+	if err := notifier.Notify(context.Background(), res); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("payloads = %v", received)
+	}
+	got := received[0]
+	if got["event"] != EventAmbiguousMatch || got["status"] != BatchStatusAmbiguousMatch || got["ambiguous_match"] != true {
+		t.Errorf("payload = %v", got)
+	}
+	if score, ok := got["match_score"].(float64); !ok || score >= minMatchScore {
+		t.Errorf("match_score = %v, want a weak score", got["match_score"])
+	}
+	message, _ := got["message"].(string)
+	for _, want := range []string{"【物件の照合要確認】クレール長居 A棟", "- クレール長居Ⅱ 201号室 募集中", "- クレール長居公園 102号室 募集中"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message missing %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestNotifierReportsFailedDelivery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewNotifier(NotifyConfig{Sinks: []SinkConfig{{Name: "ops", Type: "slack", URL: srv.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := BatchResult{BatchItem: BatchItem{PropertyName: "クレール住吉"}, ConfirmedAt: time.Now()}
+	failed.setError(ErrLoginRejected)
+
+	err = notifier.Notify(context.Background(), failed)
+	if err == nil || !strings.Contains(err.Error(), "ops") || !strings.Contains(err.Error(), "no_service") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNewNotifierRejectsInvalidSinks(t *testing.T) {
+	for _, sc := range []SinkConfig{
+		{Type: "pager"},
+		{Type: "slack"},
+		{Type: "email", SMTP: &SMTPConfig{Addr: "localhost:25"}},
+		{Type: "webhook", URL: "http://localhost", Events: []string{"moved"}},
+		{Type: "webhook", URL: "http://localhost", Template: "{{.PropertyName"},
+	} {
+		if _, err := NewNotifier(NotifyConfig{Sinks: []SinkConfig{sc}}); err == nil {
+			t.Errorf("%+v: expected an error", sc)
+		}
+	}
+}
+
+func TestNotifierEmail(t *testing.T) {
+	addr, mails := fakeSMTPServer(t)
+
+	notifier, err := NewNotifier(NotifyConfig{Sinks: []SinkConfig{{
+		Type: "email",
+		SMTP: &SMTPConfig{Addr: addr, From: "crm@example.com", To: []string{"leasing@example.com"}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), changedResult("クレール住吉")); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	select {
+	case mail := <-mails:
+		header, body, _ := strings.Cut(mail, "\n\n")
+		var subject string
+		for _, line := range strings.Split(header, "\n") {
+			if s, ok := strings.CutPrefix(line, "Subject: "); ok {
+				subject, _ = new(mime.WordDecoder).DecodeHeader(s)
+			}
+		}
+		if subject != "[ITANDI BB] 募集終了: クレール住吉" {
+			t.Errorf("subject = %q", subject)
+		}
+		if !strings.Contains(body, "募集終了: 302号室") {
+			t.Errorf("body = %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+}
+
+// fakeSMTPServer accepts one SMTP session and sends the DATA it received,
+// with line endings normalized to \n
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	mails := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := io.ReadAll(tp.DotReader())
+				mails <- string(data)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), mails
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Output formats
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+)
+
+// outputSheet is the worksheet name of xlsx output
+const outputSheet = "確認結果"
+
+// resultColumns are the column headers of csv and xlsx output, in ResultRow field order
+var resultColumns = []string{
+	"物件名", "部屋番号", "賃料", "管理費", "敷金", "礼金",
+	"間取り", "面積(㎡)", "入居時期", "募集状況", "管理会社", "確認日時",
+	"一致度", "照合要確認",
+}
+
+// ResultRow は出力1行分（1部屋、または部屋のない確認結果）
+type ResultRow struct {
+	PropertyName      string    `json:"property_name"`
+	RoomNumber        string    `json:"room_number"`
+	Rent              *int      `json:"rent"`
+	ManagementFee     *int      `json:"management_fee"`
+	Deposit           *int      `json:"deposit"`
+	KeyMoney          *int      `json:"key_money"`
+	Layout            string    `json:"layout"`
+	AreaSqm           *float64  `json:"area_sqm"`
+	AvailableDate     string    `json:"available_date"`
+	Status            string    `json:"status"`
+	ManagementCompany string    `json:"management_company"`
+	ConfirmedAt       time.Time `json:"confirmed_at"`
+
+	// MatchScore and AmbiguousMatch come from the building match (see
+	// NameMatch); the score is empty when no name was matched
+	MatchScore     *float64 `json:"match_score"`
+	AmbiguousMatch bool     `json:"ambiguous_match"`
+}
+
+// OutputOptions selects where and in which format results are written
+type OutputOptions struct {
+	// Format is json, jsonl, csv or xlsx; empty infers it from Path, else json
+	Format string
+
+	// Path is the file to write; empty or "-" writes to stdout
+	Path string
+}
+
+// Enabled reports whether an output was requested
+func (o OutputOptions) Enabled() bool {
+	return o.Format != "" || o.Path != ""
+}
+
+// ToStdout reports whether results go to stdout
+func (o OutputOptions) ToStdout() bool {
+	return o.Path == "" || o.Path == "-"
+}
+
+// resolve fills in the format from the file extension and validates it
+func (o OutputOptions) resolve() (OutputOptions, error) {
+	if o.Format == "" && !o.ToStdout() {
+		o.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(o.Path)), ".")
+		if o.Format == "ndjson" {
+			o.Format = FormatJSONL
+		}
+	}
+	o.Format = strings.ToLower(orDefault(o.Format, FormatJSON))
+
+	switch o.Format {
+	case FormatJSON, FormatJSONL, FormatCSV:
+	case FormatXLSX:
+		if o.ToStdout() {
+			return o, fmt.Errorf("xlsx output needs a file (-output results.xlsx)")
+		}
+	default:
+		return o, fmt.Errorf("unknown output format %q (json, jsonl, csv or xlsx)", o.Format)
+	}
+	return o, nil
+}
+
+// Validate checks the format before any confirmation runs
+func (o OutputOptions) Validate() error {
+	_, err := o.resolve()
+	return err
+}
+
+// Write writes one row per listing of results to the file or stdout
+func (o OutputOptions) Write(results []BatchResult) error {
+	o, err := o.resolve()
+	if err != nil {
+		return err
+	}
+	rows := ResultRows(results)
+
+	if o.Format == FormatXLSX {
+		return writeXLSX(o.Path, rows)
+	}
+
+	var w io.Writer = os.Stdout
+	if !o.ToStdout() {
+		f, err := os.Create(o.Path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	if err := writeRows(bw, o.Format, rows); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ResultRows flattens results into one row per listing. A result without
+// listings (no results or an error) becomes a single row describing it.
+func ResultRows(results []BatchResult) []ResultRow {
+	var rows []ResultRow
+	for _, res := range results {
+		var listings []PropertyListing
+		var score *float64
+		var ambiguous bool
+		if res.Result != nil {
+			listings = res.Result.Listings
+			if m := res.Result.Match; m != nil {
+				score, ambiguous = &m.Score, m.Ambiguous
+			}
+		}
+		if len(listings) == 0 {
+			rows = append(rows, ResultRow{
+				PropertyName:   res.PropertyName,
+				RoomNumber:     res.RoomNumber,
+				Status:         resultStatusLabel(res),
+				ConfirmedAt:    res.ConfirmedAt,
+				MatchScore:     score,
+				AmbiguousMatch: ambiguous,
+			})
+			continue
+		}
+
+		for _, l := range listings {
+			rows = append(rows, ResultRow{
+				PropertyName:      orDefault(l.Name, res.PropertyName),
+				RoomNumber:        orDefault(l.RoomNumber, res.RoomNumber),
+				Rent:              &l.Rent,
+				ManagementFee:     &l.ManagementFee,
+				Deposit:           &l.Deposit,
+				KeyMoney:          &l.KeyMoney,
+				Layout:            l.Layout,
+				AreaSqm:           &l.AreaSqm,
+				AvailableDate:     l.AvailableDate,
+				Status:            l.Status,
+				ManagementCompany: l.ManagementCompany,
+				ConfirmedAt:       res.ConfirmedAt,
+				MatchScore:        score,
+				AmbiguousMatch:    ambiguous,
+			})
+		}
+	}
+	return rows
+}
+
+// resultStatusLabel describes a result that has no listings
+func resultStatusLabel(res BatchResult) string {
+	switch res.Status {
+	case BatchStatusError:
+		return fmt.Sprintf("エラー（%s）", orDefault(res.ErrorCode, "unknown"))
+	case BatchStatusNoResults:
+		return "該当なし"
+	case BatchStatusRoomNotListed:
+		return "部屋の掲載なし"
+	case BatchStatusAmbiguousMatch:
+		return "物件の照合要確認"
+	}
+	return res.Status
+}
+
+// writeRows writes rows as json, jsonl or csv
+func writeRows(w io.Writer, format string, rows []ResultRow) error {
+	switch format {
+	case FormatJSON:
+		if rows == nil {
+			rows = []ResultRow{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatCSV:
+		// A BOM makes Excel read the Japanese text as UTF-8
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+		cw := csv.NewWriter(w)
+		cw.Write(resultColumns)
+		for _, row := range rows {
+			cw.Write(row.strings())
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// strings returns the row's cells as text, leaving missing numbers empty
+func (r ResultRow) strings() []string {
+	num := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	area := ""
+	if r.AreaSqm != nil {
+		area = strconv.FormatFloat(*r.AreaSqm, 'f', -1, 64)
+	}
+	score := ""
+	if r.MatchScore != nil {
+		score = strconv.FormatFloat(*r.MatchScore, 'f', 2, 64)
+	}
+	return []string{
+		r.PropertyName, r.RoomNumber,
+		num(r.Rent), num(r.ManagementFee), num(r.Deposit), num(r.KeyMoney),
+		r.Layout, area, r.AvailableDate, r.Status, r.ManagementCompany,
+		r.ConfirmedAt.Local().Format("2006-01-02 15:04:05"),
+		score, r.ambiguousLabel(),
+	}
+}
+
+// cells returns the row's cells with numbers and the time kept typed for xlsx
+func (r ResultRow) cells() []any {
+	num := func(v *int) any {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	var area, score any
+	if r.AreaSqm != nil {
+		area = *r.AreaSqm
+	}
+	if r.MatchScore != nil {
+		score = *r.MatchScore
+	}
+	return []any{
+		r.PropertyName, r.RoomNumber,
+		num(r.Rent), num(r.ManagementFee), num(r.Deposit), num(r.KeyMoney),
+		r.Layout, area, r.AvailableDate, r.Status, r.ManagementCompany,
+		r.ConfirmedAt.Local(),
+		score, r.ambiguousLabel(),
+	}
+}
+
+// ambiguousLabel marks rows whose building match needs checking
+func (r ResultRow) ambiguousLabel() string {
+	if r.AmbiguousMatch {
+		return "要確認"
+	}
+	return ""
+}
+
+// writeXLSX writes rows to an Excel workbook with a bold, frozen header
+func writeXLSX(path string, rows []ResultRow) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", outputSheet); err != nil {
+		return err
+	}
+
+	header := make([]any, len(resultColumns))
+	for i, c := range resultColumns {
+		header[i] = c
+	}
+	if err := f.SetSheetRow(outputSheet, "A1", &header); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cells := row.cells()
+		if err := f.SetSheetRow(outputSheet, fmt.Sprintf("A%d", i+2), &cells); err != nil {
+			return err
+		}
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	yen, err := f.NewStyle(&excelize.Style{NumFmt: 3}) // #,##0
+	if err != nil {
+		return err
+	}
+	datetime, err := f.NewStyle(&excelize.Style{CustomNumFmt: ptr("yyyy-mm-dd hh:mm")})
+	if err != nil {
+		return err
+	}
+	last := len(rows) + 1
+	f.SetCellStyle(outputSheet, "A1", "N1", bold)
+	if last > 1 {
+		f.SetCellStyle(outputSheet, "C2", fmt.Sprintf("F%d", last), yen)
+		f.SetCellStyle(outputSheet, "L2", fmt.Sprintf("L%d", last), datetime)
+	}
+	f.SetColWidth(outputSheet, "A", "A", 28)
+	f.SetColWidth(outputSheet, "I", "K", 16)
+	f.SetColWidth(outputSheet, "L", "L", 18)
+	f.SetColWidth(outputSheet, "N", "N", 12)
+	f.SetPanes(outputSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
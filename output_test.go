@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// outputResults is a found result with two rooms and a failed one
+func outputResults() []BatchResult {
+	at := time.Date(2026, 4, 1, 9, 30, 0, 0, time.Local)
+	found := BatchResult{BatchItem: BatchItem{PropertyName: "クレール住吉"}, ConfirmedAt: at}
+	found.setResult(&SearchResult{Status: SearchStatusFound, Listings: []PropertyListing{
+		{Name: "クレール住吉", RoomNumber: "302", Rent: 77000, ManagementFee: 5000, Deposit: 77000, Layout: "1K", AreaSqm: 25.5, AvailableDate: "即入居", Status: "募集中", ManagementCompany: "クレール管理"},
+		{Name: "クレール住吉", RoomNumber: "101", Rent: 70000, Status: "申込あり"},
+	}})
+	failed := BatchResult{BatchItem: BatchItem{PropertyName: "サンプル物件", RoomNumber: "201"}, ConfirmedAt: at}
+	failed.setError(ErrSessionExpired)
+	return []BatchResult{found, failed}
+}
+
+func TestWriteRowsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRows(&buf, FormatCSV, ResultRows(outputResults())); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "\ufeff") {
+		t.Error("CSV should start with a BOM for Excel")
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(resultColumns, ",") {
+		t.Fatalf("records = %v", records)
+	}
+	want := []string{"クレール住吉", "302", "77000", "5000", "77000", "0", "1K", "25.5", "即入居", "募集中", "クレール管理", "2026-04-01 09:30:00", "", ""}
+	if strings.Join(records[1], ",") != strings.Join(want, ",") {
+		t.Errorf("row = %v, want %v", records[1], want)
+	}
+	if got := records[3]; got[0] != "サンプル物件" || got[1] != "201" || got[2] != "" || got[9] != "エラー（session_expired）" {
+		t.Errorf("error row = %v", got)
+	}
+}
+
+func TestResultRowsMatch(t *testing.T) {
+	r := &SearchResult{Status: SearchStatusFound, Listings: []PropertyListing{
+		{Name: "クレール", RoomNumber: "101", Address: "大阪府大阪市住吉区長居1-2-3"},
+		{Name: "クレール", RoomNumber: "202", Address: "兵庫県神戸市中央区港島1-1"},
+	}}
+	r.SelectBuilding(MatchTarget{Name: "クレール"})
+	res := BatchResult{BatchItem: BatchItem{PropertyName: "クレール"}, ConfirmedAt: time.Date(2026, 4, 1, 9, 30, 0, 0, time.Local)}
+	res.setResult(r)
+
+	rows := ResultRows([]BatchResult{res})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want both candidates' rooms", rows)
+	}
+	for _, row := range rows {
+		if row.MatchScore == nil || *row.MatchScore != 1 || !row.AmbiguousMatch {
+			t.Errorf("row = %+v, want score 1 and ambiguous", row)
+		}
+		cells := row.strings()
+		if got := cells[len(cells)-2:]; got[0] != "1.00" || got[1] != "要確認" {
+			t.Errorf("match cells = %v", got)
+		}
+	}
+}
+
+func TestWriteRowsJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRows(&buf, FormatJSONL, ResultRows(outputResults())); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], `{"property_name":"クレール住吉","room_number":"302","rent":77000,`) {
+		t.Errorf("columns out of order: %s", lines[0])
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["rent"] != nil || row["status"] != "エラー（session_expired）" {
+		t.Errorf("error row = %v", row)
+	}
+	if row["match_score"] != nil || row["ambiguous_match"] != false {
+		t.Errorf("error row match = %v, %v", row["match_score"], row["ambiguous_match"])
+	}
+}
+
+func TestOutputOptionsWriteXLSX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.xlsx")
+	if err := (OutputOptions{Path: path}).Write(outputResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(outputSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][2] != "賃料" || rows[1][0] != "クレール住吉" || rows[1][2] != "77,000" {
+		t.Errorf("rows = %v", rows)
+	}
+	if v, _ := f.GetCellValue(outputSheet, "C2", excelize.Options{RawCellValue: true}); v != "77000" {
+		t.Errorf("rent should be stored as a number, got %q", v)
+	}
+}
+
+func TestOutputOptionsResolve(t *testing.T) {
+	tests := []struct {
+		opts    OutputOptions
+		want    string
+		wantErr bool
+	}{
+		{OutputOptions{}, FormatJSON, false},
+		{OutputOptions{Path: "out.csv"}, FormatCSV, false},
+		{OutputOptions{Path: "out.ndjson"}, FormatJSONL, false},
+		{OutputOptions{Format: "JSONL", Path: "out.txt"}, FormatJSONL, false},
+		{OutputOptions{Format: "xlsx"}, "", true},
+		{OutputOptions{Path: "out.txt"}, "", true},
+		{OutputOptions{Format: "yaml"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.resolve()
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got.Format != tt.want) {
+			t.Errorf("%+v: got %q, %v", tt.opts, got.Format, err)
+		}
+	}
+}
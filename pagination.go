@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// maxResultPages bounds how many result pages one search walks
+const maxResultPages = 50
+
+// scrollLoadTimeout is how long an infinitely scrolled list may take to show
+// more cards after scrolling to the bottom
+const scrollLoadTimeout = 3 * time.Second
+
+// zeroResultsPattern matches a "0件" count without also matching "10件"
+var zeroResultsPattern = regexp.MustCompile(`(^|[^\d,])0\s*件`)
+
+// resultCountPattern matches the labelled total of a result page such as
+// "検索結果 12件". Other "N件" on the page (notices, saved searches) are not
+// the number of results. The pattern is valid in both Go and JavaScript.
+const resultCountPattern = `(?:検索結果|該当|全)\s*[:：]?\s*([\d,]+)\s*件`
+
+// resultCountRe is resultCountPattern compiled for Go
+var resultCountRe = regexp.MustCompile(resultCountPattern)
+
+// resultCardLinks selects the listing links of result cards, leaving out
+// pager links back to the list itself
+const resultCardLinks = `a[href*="/rent_rooms/"]:not([href*="/rent_rooms/list"])`
+
+// collectResultPages appends the listings of the result pages after the first.
+// It follows the results.next_page control when the page has one and
+// otherwise scrolls to the bottom for lists that load more cards on scroll.
+// It stops at the reported result count, on the last page, or when a page
+// adds no new listings; a page that cannot be read ends the walk with a
+// warning and leaves the count check to report the shortfall.
+func (s *ITANDIScraper) collectResultPages(result *SearchResult) {
+	seen := make(map[string]bool, len(result.Listings))
+	for _, l := range result.Listings {
+		seen[listingKey(l)] = true
+	}
+
+	result.Diagnostics.ResultPages = 1
+	for result.Diagnostics.ResultPages < maxResultPages {
+		if result.ResultCount > 0 && len(result.Listings) >= result.ResultCount {
+			return
+		}
+
+		more, err := s.nextResultPage()
+		if err != nil {
+			log.Printf("Warning: could not open the next result page: %v\n", err)
+			return
+		}
+		if !more {
+			return
+		}
+		result.Diagnostics.ResultPages++
+
+		extraction, err := s.extractResultPage()
+		if err != nil {
+			log.Printf("Warning: could not read result page %d: %v\n", result.Diagnostics.ResultPages, err)
+			return
+		}
+		added := 0
+		for _, raw := range extraction.Properties {
+			l := newPropertyListing(raw)
+			if key := listingKey(l); !seen[key] {
+				seen[key] = true
+				result.Listings = append(result.Listings, l)
+				added++
+			}
+		}
+		log.Printf("Result page %d: %d new listings (%d so far)\n", result.Diagnostics.ResultPages, added, len(result.Listings))
+		if added == 0 {
+			return
+		}
+	}
+	log.Printf("Warning: stopped after %d result pages\n", maxResultPages)
+}
+
+// nextResultPage shows the next results, reporting false when there are none
+func (s *ITANDIScraper) nextResultPage() (bool, error) {
+	first, cards := s.resultCards()
+
+	for _, locator := range s.selectors.Get("results.next_page") {
+		if !locatorExists(s.ctx, []string{locator}) {
+			continue
+		}
+		if err := clickLocator(s.ctx, locator); err != nil {
+			return false, fmt.Errorf("%w: next result page: %w", ErrNavigationFailed, err)
+		}
+		if err := waitFor(s.ctx, "next result page", s.waits.Results,
+			waitResultsReplaced(first), waitDOMReady(), waitNetworkIdle(s.network)); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// Without a pager, an infinitely scrolled list loads more cards at the bottom
+	if err := chromedp.Run(s.ctx, chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil)); err != nil {
+		return false, fmt.Errorf("%w: scrolling results: %w", ErrNavigationFailed, err)
+	}
+	err := waitFor(s.ctx, "more results", scrollLoadTimeout, waitMoreResultCards(cards))
+	if errors.Is(err, ErrTimeout) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// resultCards returns the first card's link and the number of cards on the page
+func (s *ITANDIScraper) resultCards() (string, int) {
+	var cards struct {
+		First string `json:"first"`
+		Count int    `json:"count"`
+	}
+	chromedp.Run(s.ctx, chromedp.Evaluate(fmt.Sprintf(`
+		(() => {
+			const links = document.querySelectorAll('%s');
+			return {first: links.length > 0 ? links[0].href : '', count: links.length};
+		})()
+	`, resultCardLinks), &cards))
+	return cards.First, cards.Count
+}
+
+// waitResultsReplaced waits for the first result card to link somewhere else than first
+func waitResultsReplaced(first string) waitCondition {
+	return waitJS("result cards to change", fmt.Sprintf(`
+		(() => {
+			const link = document.querySelector('%s');
+			return link !== null && link.href !== %q;
+		})()
+	`, resultCardLinks, first))
+}
+
+// waitMoreResultCards waits for more than n result cards
+func waitMoreResultCards(n int) waitCondition {
+	return waitJS(fmt.Sprintf("more than %d result cards", n), fmt.Sprintf(
+		`document.querySelectorAll('%s').length > %d`, resultCardLinks, n))
+}
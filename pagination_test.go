@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestZeroResultsPattern(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"検索結果 0件", true},
+		{"0件", true},
+		{"検索結果0 件です", true},
+		{"検索結果 10件", false},
+		{"検索結果 1,000件", false},
+		{"検索結果 3件", false},
+	}
+	for _, tt := range tests {
+		if got := zeroResultsPattern.MatchString(tt.text); got != tt.want {
+			t.Errorf("zeroResultsPattern.MatchString(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestResultCountPattern(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // captured count, "" for no match
+	}{
+		{"検索結果 12件", "12"},
+		{"検索結果：1,234件", "1,234"},
+		{"該当 3件", "3"},
+		{"全 45 件", "45"},
+		{"お知らせ 12件", ""},
+		{"保存した条件 5件", ""},
+		{"12件", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if m := resultCountRe.FindStringSubmatch(tt.text); m != nil {
+			got = m[1]
+		}
+		if got != tt.want {
+			t.Errorf("resultCountRe on %q = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// healthCheckTimeout bounds the liveness probe run on a tab before each job
+const healthCheckTimeout = 5 * time.Second
+
+// ErrPoolClosed is returned for confirmations submitted after the pool shut down
+var ErrPoolClosed = errors.New("browser pool closed")
+
+// PoolOptions configures a BrowserPool
+type PoolOptions struct {
+	// Workers is the number of tabs confirming properties in parallel
+	Workers int
+
+	// RecycleAfter closes and reopens a tab after this many jobs; 0 never recycles
+	RecycleAfter int
+
+	// MinInterval is the minimum time between two confirmations starting,
+	// across all workers, so ITANDI BB is not hammered
+	MinInterval time.Duration
+
+	Selectors *SelectorStore
+	Retry     RetryPolicy
+
+	// Waits overrides the default wait limits when non-zero
+	Waits WaitTimeouts
+
+	// DetailPages reads each listing's 詳細 page; see ITANDIScraper.UseDetailPages
+	DetailPages bool
+}
+
+// DefaultPoolOptions returns a single worker that starts at most one confirmation per second
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		Workers:      1,
+		RecycleAfter: 50,
+		MinInterval:  time.Second,
+		Retry:        DefaultRetryPolicy(),
+	}
+}
+
+// PoolStats is a snapshot of the pool's workers
+type PoolStats struct {
+	Workers   int `json:"workers"`
+	Ready     int `json:"ready"`
+	Busy      int `json:"busy"`
+	Completed int `json:"completed"`
+	Recycled  int `json:"recycled"`
+}
+
+// BrowserPool runs confirmations on several logged-in tabs of one Chromium.
+// Tabs share the browser's cookies, so the login of the first tab carries
+// over to the others.
+type BrowserPool struct {
+	opts    PoolOptions
+	site    SiteConfig
+	session SessionOptions
+
+	browserCtx   context.Context
+	closeBrowser context.CancelFunc
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	jobs    chan poolJob
+	limiter *rateLimiter
+
+	mu    sync.Mutex
+	stats PoolStats
+	live  int // workers that have not given up on reopening their tab
+}
+
+// poolJob is one confirmation waiting for a worker
+type poolJob struct {
+	ctx   context.Context
+	item  BatchItem
+	reply chan BatchResult
+}
+
+// poolWorker is one tab of the pool
+type poolWorker struct {
+	id      int
+	scraper *ITANDIScraper
+	crashed atomic.Bool
+	jobs    int
+}
+
+// NewBrowserPool starts Chromium, logs in the first tab and starts the workers
+func NewBrowserPool(cfg BrowserConfig, site SiteConfig, session SessionOptions, opts PoolOptions) (*BrowserPool, error) {
+	if opts.Workers < 1 {
+		return nil, fmt.Errorf("pool needs at least 1 worker, got %d", opts.Workers)
+	}
+	if session.ProfileDir != "" {
+		cfg.UserDataDir = session.ProfileDir
+	}
+
+	browserCtx, closeBrowser, err := newBrowserContext(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := chromedp.Run(browserCtx); err != nil {
+		closeBrowser()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	p := &BrowserPool{
+		opts:         opts,
+		site:         site,
+		session:      session,
+		browserCtx:   browserCtx,
+		closeBrowser: closeBrowser,
+		ctx:          ctx,
+		cancel:       cancel,
+		jobs:         make(chan poolJob),
+		limiter:      &rateLimiter{interval: opts.MinInterval},
+		stats:        PoolStats{Workers: opts.Workers},
+		live:         opts.Workers,
+	}
+
+	// Log in once up front so a bad password fails the pool instead of every job
+	first, err := p.openWorker(1)
+	if err != nil {
+		cancel(err)
+		closeBrowser()
+		return nil, err
+	}
+
+	p.wg.Add(opts.Workers)
+	go p.work(first)
+	for id := 2; id <= opts.Workers; id++ {
+		go func() {
+			w, err := p.reopenWorker(id)
+			if err != nil {
+				p.workerLost(id, err)
+				p.wg.Done()
+				return
+			}
+			p.work(w)
+		}()
+	}
+
+	log.Printf("Browser pool started with %d workers\n", opts.Workers)
+	return p, nil
+}
+
+// Confirm queues item for the next free worker and waits for its result
+func (p *BrowserPool) Confirm(ctx context.Context, item BatchItem) BatchResult {
+	job := poolJob{ctx: ctx, item: item, reply: make(chan BatchResult, 1)}
+
+	select {
+	case p.jobs <- job:
+	case <-ctx.Done():
+		return failedResult(item, ctx.Err())
+	case <-p.ctx.Done():
+		return failedResult(item, context.Cause(p.ctx))
+	}
+
+	select {
+	case res := <-job.reply:
+		return res
+	case <-ctx.Done():
+		return failedResult(item, ctx.Err())
+	}
+}
+
+// Stats returns a snapshot of the workers
+func (p *BrowserPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Close waits for running confirmations to finish and shuts down Chromium
+func (p *BrowserPool) Close() {
+	p.cancel(ErrPoolClosed)
+	p.wg.Wait()
+	p.closeBrowser()
+}
+
+// work takes jobs until the pool is closed, recycling its tab when it
+// crashes, fails a health check or has done RecycleAfter jobs
+func (p *BrowserPool) work(w *poolWorker) {
+	defer p.wg.Done()
+	defer func() { p.closeWorker(w) }()
+	id := w.id
+
+	for {
+		var job poolJob
+		select {
+		case <-p.ctx.Done():
+			return
+		case job = <-p.jobs:
+		}
+
+		if !w.healthy() {
+			var err error
+			if w, err = p.recycle(w, "health check failed"); err != nil {
+				job.reply <- failedResult(job.item, err)
+				p.workerLost(id, err)
+				return
+			}
+		}
+
+		if err := p.limiter.Wait(job.ctx); err != nil {
+			job.reply <- failedResult(job.item, err)
+			continue
+		}
+
+		p.mu.Lock()
+		p.stats.Busy++
+		p.mu.Unlock()
+		res := w.confirm(job.item)
+		p.mu.Lock()
+		p.stats.Busy--
+		p.stats.Completed++
+		p.mu.Unlock()
+		job.reply <- res
+
+		w.jobs++
+		var err error
+		switch {
+		case w.crashed.Load():
+			w, err = p.recycle(w, "tab crashed")
+		case res.Status == BatchStatusError && !w.healthy():
+			w, err = p.recycle(w, "tab unresponsive after error")
+		case p.opts.RecycleAfter > 0 && w.jobs >= p.opts.RecycleAfter:
+			w, err = p.recycle(w, fmt.Sprintf("%d jobs done", w.jobs))
+		}
+		if err != nil {
+			p.workerLost(id, err)
+			return
+		}
+	}
+}
+
+// openWorker opens a tab and makes sure it is logged in
+func (p *BrowserPool) openWorker(id int) (*poolWorker, error) {
+	scraper := newITANDIScraperTab(p.browserCtx, p.site, p.session)
+	if p.opts.Selectors != nil {
+		scraper.UseSelectors(p.opts.Selectors)
+	}
+	scraper.UseRetryPolicy(p.opts.Retry)
+	scraper.UseDetailPages(p.opts.DetailPages)
+	if p.opts.Waits != (WaitTimeouts{}) {
+		scraper.UseWaitTimeouts(p.opts.Waits)
+	}
+
+	w := &poolWorker{id: id, scraper: scraper}
+	chromedp.ListenTarget(scraper.ctx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			w.crashed.Store(true)
+		}
+	})
+
+	// Another tab may already have logged in the shared browser
+	if valid, err := scraper.IsSessionValid(); err != nil || !valid {
+		if err := scraper.EnsureLoggedIn(); err != nil {
+			scraper.Close()
+			return nil, fmt.Errorf("worker %d: %w", id, err)
+		}
+	}
+
+	p.mu.Lock()
+	p.stats.Ready++
+	p.mu.Unlock()
+	log.Printf("Worker %d ready\n", id)
+	return w, nil
+}
+
+// reopenWorker opens a tab, backing off per the retry policy. It gives up
+// after Retry.MaxAttempts failures or when the pool is closed.
+func (p *BrowserPool) reopenWorker(id int) (*poolWorker, error) {
+	attempts := max(p.opts.Retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		w, err := p.openWorker(id)
+		if err == nil {
+			return w, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("failed to open tab after %d attempts: %w", attempt, err)
+		}
+		wait := max(p.opts.Retry.Backoff(attempt), time.Second)
+		log.Printf("Worker %d: failed to open tab: %v - retrying in %s\n", id, err, wait.Round(10*time.Millisecond))
+
+		select {
+		case <-p.ctx.Done():
+			return nil, context.Cause(p.ctx)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// workerLost records that a worker stopped because its tab could not be
+// reopened. When no worker is left the pool shuts down, failing queued and
+// future confirmations instead of leaving them waiting forever.
+func (p *BrowserPool) workerLost(id int, err error) {
+	if p.ctx.Err() != nil {
+		return // closing, not lost
+	}
+	log.Printf("Worker %d: giving up: %v\n", id, err)
+
+	p.mu.Lock()
+	p.live--
+	live := p.live
+	p.mu.Unlock()
+	if live == 0 {
+		p.cancel(fmt.Errorf("%w: no worker could open a tab: %w", ErrPoolClosed, err))
+	}
+}
+
+// recycle replaces w's tab with a fresh one
+func (p *BrowserPool) recycle(w *poolWorker, reason string) (*poolWorker, error) {
+	log.Printf("Worker %d: recycling tab (%s)\n", w.id, reason)
+	p.closeWorker(w)
+
+	p.mu.Lock()
+	p.stats.Recycled++
+	p.mu.Unlock()
+
+	return p.reopenWorker(w.id)
+}
+
+// closeWorker closes w's tab
+func (p *BrowserPool) closeWorker(w *poolWorker) {
+	if w == nil || w.scraper == nil {
+		return
+	}
+	w.scraper.Close()
+	w.scraper = nil
+
+	p.mu.Lock()
+	p.stats.Ready--
+	p.mu.Unlock()
+}
+
+// healthy probes the tab with a trivial script
+func (w *poolWorker) healthy() bool {
+	if w.crashed.Load() {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(w.scraper.ctx, healthCheckTimeout)
+	defer cancel()
+
+	var state string
+	return chromedp.Run(ctx, chromedp.Evaluate(`document.readyState`, &state)) == nil
+}
+
+// confirm runs one confirmation, turning a panic into a failed result
+func (w *poolWorker) confirm(item BatchItem) (res BatchResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.crashed.Store(true)
+			res = failedResult(item, fmt.Errorf("worker %d panicked: %v", w.id, r))
+		}
+	}()
+	return confirmBatchItem(w.scraper, item)
+}
+
+// failedResult is the result for an item that could not be confirmed
+func failedResult(item BatchItem, err error) BatchResult {
+	res := BatchResult{BatchItem: item, ConfirmedAt: time.Now()}
+	res.setError(err)
+	return res
+}
+
+// rateLimiter spaces out events by at least interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait blocks until the next slot is free or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(slot)):
+		return nil
+	}
+}
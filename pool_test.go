@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesEvents(t *testing.T) {
+	l := &rateLimiter{interval: 50 * time.Millisecond}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first event starts immediately, the fourth three intervals later
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 events took %s, want at least 150ms", elapsed)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	l := &rateLimiter{interval: time.Hour}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBrowserPoolConfirmsConcurrently(t *testing.T) {
+	srv := newMockITANDIServer(t)
+	cfg := mockBrowserConfig(t)
+
+	opts := DefaultPoolOptions()
+	opts.Workers = 3
+	// With 3 workers and 4 rows at least one tab is recycled before its second row finishes
+	opts.RecycleAfter = 1
+	opts.MinInterval = 0
+	opts.Retry = RetryPolicy{MaxAttempts: 1, Multiplier: 1}
+	opts.Waits = mockWaitTimeouts
+
+	pool, err := NewBrowserPool(cfg, srv.SiteConfig(), SessionOptions{}, opts)
+	if err != nil {
+		t.Fatalf("NewBrowserPool: %v", err)
+	}
+	defer pool.Close()
+
+	items := []BatchItem{
+		{Line: 1, PropertyName: "クレール"},
+		{Line: 2, PropertyName: "存在しない物件"},
+		{Line: 3, PropertyName: "クレール", RoomNumber: "302"},
+		{Line: 4, PropertyName: "クレール", RoomNumber: "101"},
+	}
+	results := make([]BatchResult, len(items))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = pool.Confirm(context.Background(), item)
+		}()
+	}
+	wg.Wait()
+
+	want := []string{BatchStatusFound, BatchStatusNoResults, BatchStatusFound, BatchStatusRoomNotListed}
+	for i, res := range results {
+		if res.Status != want[i] {
+			t.Errorf("row %d status = %q (%s), want %q", items[i].Line, res.Status, res.Error, want[i])
+		}
+	}
+
+	stats := pool.Stats()
+	if stats.Completed != len(items) {
+		t.Errorf("Completed = %d, want %d", stats.Completed, len(items))
+	}
+}
+
+func TestBrowserPoolFailsJobsWhenEveryWorkerIsLost(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	p := &BrowserPool{ctx: ctx, cancel: cancel, jobs: make(chan poolJob), live: 2}
+
+	queued := make(chan BatchResult, 1)
+	go func() { queued <- p.Confirm(context.Background(), BatchItem{Line: 1, PropertyName: "クレール"}) }()
+
+	reopenErr := errors.New("failed to open tab after 3 attempts")
+	p.workerLost(1, reopenErr)
+	select {
+	case res := <-queued:
+		t.Fatalf("job failed while a worker was left: %+v", res)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.workerLost(2, reopenErr)
+	select {
+	case res := <-queued:
+		if res.Status != BatchStatusError || !strings.Contains(res.Error, "failed to open tab") {
+			t.Errorf("queued job = %+v, want an error naming the reopen failure", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued job still waiting after every worker was lost")
+	}
+	if err := context.Cause(p.ctx); !errors.Is(err, ErrPoolClosed) || !errors.Is(err, reopenErr) {
+		t.Errorf("cause = %v, want ErrPoolClosed wrapping the reopen error", err)
+	}
+
+	// Later jobs fail at once
+	if res := p.Confirm(context.Background(), BatchItem{Line: 2}); res.Status != BatchStatusError {
+		t.Errorf("job after shutdown = %+v", res)
+	}
+}
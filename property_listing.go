@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchStatus は検索結果ページの状態
+type SearchStatus string
+
+const (
+	SearchStatusUnknown   SearchStatus = ""
+	SearchStatusFound     SearchStatus = "results_found"
+	SearchStatusNoResults SearchStatus = "no_results"
+
+	// SearchStatusRoomNotListed means the building was found but not the requested room
+	SearchStatusRoomNotListed SearchStatus = "room_not_listed"
+
+	// SearchStatusAmbiguousMatch means the results could not be narrowed to
+	// one building with confidence; see SearchResult.Match
+	SearchStatusAmbiguousMatch SearchStatus = "ambiguous_match"
+)
+
+// PropertyListing は検索結果に表示された1部屋分の募集情報
+type PropertyListing struct {
+	Name              string  `json:"name"`
+	RoomNumber        string  `json:"room_number,omitempty"`
+	Address           string  `json:"address,omitempty"`
+	URL               string  `json:"url,omitempty"`
+	Rent              int     `json:"rent"`
+	ManagementFee     int     `json:"management_fee"`
+	Deposit           int     `json:"deposit"`
+	DepositMonths     float64 `json:"deposit_months,omitempty"`
+	KeyMoney          int     `json:"key_money"`
+	KeyMoneyMonths    float64 `json:"key_money_months,omitempty"`
+	AreaSqm           float64 `json:"area_sqm"`
+	Floor             int     `json:"floor,omitempty"`
+	Layout            string  `json:"layout,omitempty"`
+	LayoutDetail      *Layout `json:"layout_detail,omitempty"`
+	AvailableDate     string  `json:"available_date,omitempty"`
+	Status            string  `json:"status,omitempty"`
+	ManagementCompany string  `json:"management_company,omitempty"`
+
+	Availability *Availability `json:"availability,omitempty"`
+
+	// Detail is the 詳細 page, read only when detail pages are enabled
+	Detail *ListingDetail `json:"detail,omitempty"`
+
+	// Raw keeps the strings exactly as extracted from the page
+	Raw map[string]string `json:"raw,omitempty"`
+}
+
+// Recruiting reports whether the listing is currently 募集中
+func (l PropertyListing) Recruiting() bool {
+	return l.Status == "募集中"
+}
+
+// SearchDiagnostics holds page information useful for debugging extraction
+type SearchDiagnostics struct {
+	PageURL               string `json:"page_url,omitempty"`
+	PageTitle             string `json:"page_title,omitempty"`
+	TableCount            int    `json:"table_count"`
+	PropertyElementsFound int    `json:"property_elements_found"`
+	ImgElementsFound      int    `json:"img_elements_found"`
+	PropertyLinks         int    `json:"property_links"`
+	FirstLinkText         string `json:"first_link_text,omitempty"`
+	FirstLinkHref         string `json:"first_link_href,omitempty"`
+	DOMSavedTo            string `json:"dom_saved_to,omitempty"`
+	ResultPages           int    `json:"result_pages,omitempty"`
+	DetailPagesRead       int    `json:"detail_pages_read,omitempty"`
+	DetailPagesFailed     int    `json:"detail_pages_failed,omitempty"`
+}
+
+// SearchResult is the typed outcome of GetPropertyDetails
+type SearchResult struct {
+	Status           SearchStatus      `json:"status"`
+	NoResultsMessage string            `json:"no_results_message,omitempty"`
+	ResultCount      int               `json:"result_count"`
+	Listings         []PropertyListing `json:"listings"`
+
+	// Incomplete is set when the number of listings read from all result
+	// pages differs from ResultCount
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// Match is how the building was chosen among the results by SelectBuilding
+	Match *NameMatch `json:"match,omitempty"`
+
+	// Room is the room number the result was narrowed to by SelectRoom
+	Room string `json:"room,omitempty"`
+
+	// OtherRooms are the rooms listed for the building when Room is not
+	OtherRooms []string `json:"other_rooms,omitempty"`
+
+	// Fields holds label/value pairs found in tables or definition lists on the page
+	Fields map[string]string `json:"fields,omitempty"`
+
+	Diagnostics SearchDiagnostics `json:"diagnostics"`
+}
+
+// HasResults reports whether the search returned any listings
+func (r *SearchResult) HasResults() bool {
+	return r.Status == SearchStatusFound
+}
+
+// Err returns an error wrapping ErrNoResults when the search found nothing,
+// ErrRoomNotListed when the requested room is not among the listings, or
+// ErrAmbiguousMatch when the requested building could not be told apart
+func (r *SearchResult) Err() error {
+	if r.Status == SearchStatusRoomNotListed {
+		return fmt.Errorf("%w: %s", ErrRoomNotListed, r.Room)
+	}
+	if r.Status == SearchStatusAmbiguousMatch && r.Match != nil {
+		return fmt.Errorf("%w: %s", ErrAmbiguousMatch, r.Match)
+	}
+	if r.HasResults() && len(r.Listings) > 0 {
+		return nil
+	}
+	if r.NoResultsMessage != "" {
+		return fmt.Errorf("%w: %s", ErrNoResults, r.NoResultsMessage)
+	}
+	return ErrNoResults
+}
+
+// SelectRoom narrows the listings to those of room ("302", "302号室" and
+// "３０２" are the same room). When the building has listings but none for
+// room, the status becomes SearchStatusRoomNotListed and OtherRooms tells
+// which rooms are listed instead. An empty room leaves the result unchanged.
+//
+// When no listing shows a room number the room cannot be looked for, so the
+// result is left unchanged and an error wrapping ErrExtractionFailed is
+// returned instead of reporting the room as not listed.
+func (r *SearchResult) SelectRoom(room string) error {
+	if room == "" {
+		return nil
+	}
+	r.Room = room
+	if r.Status != SearchStatusFound || len(r.Listings) == 0 {
+		return nil
+	}
+
+	matched := filterListingsByRoom(r.Listings, room)
+	if len(matched) == 0 {
+		var others []string
+		for _, l := range r.Listings {
+			if l.RoomNumber != "" {
+				others = append(others, l.RoomNumber)
+			}
+		}
+		if len(others) == 0 {
+			return fmt.Errorf("%w: none of the %d listings shows a room number, so room %s cannot be checked", ErrExtractionFailed, len(r.Listings), room)
+		}
+		r.Status = SearchStatusRoomNotListed
+		r.OtherRooms = others
+	}
+	r.Listings = matched
+	return nil
+}
+
+// newPropertyListing builds a listing from the raw strings extracted by the in-page script
+func newPropertyListing(raw map[string]string) PropertyListing {
+	listing := PropertyListing{
+		Name:              firstNonEmpty(raw["name"], raw["property_name"]),
+		RoomNumber:        firstNonEmpty(raw["room_number"], raw["building_number"]),
+		Address:           raw["address"],
+		URL:               raw["url"],
+		Layout:            raw["layout"],
+		AvailableDate:     raw["available_date"],
+		Status:            raw["status"],
+		ManagementCompany: raw["management_company"],
+		Raw:               raw,
+	}
+
+	listing.Rent, _ = ParseYen(raw["rent"])
+	listing.ManagementFee, _ = ParseYen(firstNonEmpty(raw["management_fee"], raw["common_fee"]))
+	if fee, err := ParseFee(raw["deposit"], listing.Rent); err == nil {
+		listing.Deposit, listing.DepositMonths = fee.Yen, fee.Months
+	}
+	if fee, err := ParseFee(raw["key_money"], listing.Rent); err == nil {
+		listing.KeyMoney, listing.KeyMoneyMonths = fee.Yen, fee.Months
+	}
+	listing.AreaSqm, _ = ParseArea(raw["area"])
+	listing.Floor, _ = ParseFloor(firstNonEmpty(raw["floor"], raw["floor_info"]))
+	if layout, err := ParseLayout(listing.Layout); err == nil {
+		listing.LayoutDetail = &layout
+	}
+	if availability, err := ParseAvailability(listing.AvailableDate, time.Now()); err == nil {
+		listing.Availability = &availability
+	}
+
+	return listing
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
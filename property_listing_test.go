@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSearchResultSelectRoom(t *testing.T) {
+	newResult := func() *SearchResult {
+		return &SearchResult{
+			Status: SearchStatusFound,
+			Listings: []PropertyListing{
+				{Name: "クレール住吉", RoomNumber: "101"},
+				{Name: "クレール住吉", RoomNumber: "302号室"},
+				{Name: "クレール住吉", RoomNumber: "B1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		room       string
+		wantStatus SearchStatus
+		wantRooms  []string
+		wantOthers []string
+	}{
+		{"", SearchStatusFound, []string{"101", "302号室", "B1"}, nil},
+		{"302", SearchStatusFound, []string{"302号室"}, nil},
+		{"３０２号室", SearchStatusFound, []string{"302号室"}, nil},
+		{"b1", SearchStatusFound, []string{"B1"}, nil},
+		{"201", SearchStatusRoomNotListed, nil, []string{"101", "302号室", "B1"}},
+	}
+	for _, tt := range tests {
+		result := newResult()
+		if err := result.SelectRoom(tt.room); err != nil {
+			t.Errorf("SelectRoom(%q) = %v", tt.room, err)
+		}
+
+		var rooms []string
+		for _, l := range result.Listings {
+			rooms = append(rooms, l.RoomNumber)
+		}
+		if result.Status != tt.wantStatus || !slices.Equal(rooms, tt.wantRooms) || !slices.Equal(result.OtherRooms, tt.wantOthers) {
+			t.Errorf("SelectRoom(%q): status = %q, rooms = %v, others = %v; want %q, %v, %v",
+				tt.room, result.Status, rooms, result.OtherRooms, tt.wantStatus, tt.wantRooms, tt.wantOthers)
+		}
+	}
+
+	result := newResult()
+	result.SelectRoom("201")
+	if !errors.Is(result.Err(), ErrRoomNotListed) {
+		t.Errorf("Err() = %v, want ErrRoomNotListed", result.Err())
+	}
+	var res BatchResult
+	res.setResult(result)
+	if res.Status != BatchStatusRoomNotListed {
+		t.Errorf("batch status = %q, want %q", res.Status, BatchStatusRoomNotListed)
+	}
+}
+
+func TestSearchResultSelectRoomWithoutRoomNumbers(t *testing.T) {
+	result := &SearchResult{
+		Status: SearchStatusFound,
+		Listings: []PropertyListing{
+			{Name: "クレール住吉", Layout: "1K"},
+			{Name: "クレール住吉", Layout: "1LDK"},
+		},
+	}
+	err := result.SelectRoom("302")
+	if !errors.Is(err, ErrExtractionFailed) {
+		t.Fatalf("SelectRoom = %v, want ErrExtractionFailed", err)
+	}
+	if ErrorCode(err) != "extraction_failed" {
+		t.Errorf("ErrorCode = %q, want extraction_failed", ErrorCode(err))
+	}
+	// Not reported as room_not_listed, and the listings are kept
+	if result.Status != SearchStatusFound || len(result.Listings) != 2 || result.OtherRooms != nil {
+		t.Errorf("status = %q, listings = %d, others = %v", result.Status, len(result.Listings), result.OtherRooms)
+	}
+
+	// One numbered card is enough to tell the room is missing
+	result.Listings = append(result.Listings, PropertyListing{Name: "クレール住吉", RoomNumber: "101"})
+	if err := result.SelectRoom("302"); err != nil {
+		t.Fatalf("SelectRoom = %v", err)
+	}
+	if result.Status != SearchStatusRoomNotListed || !slices.Equal(result.OtherRooms, []string{"101"}) {
+		t.Errorf("status = %q, others = %v", result.Status, result.OtherRooms)
+	}
+}
+
+func TestSearchResultSelectRoomWithoutResults(t *testing.T) {
+	result := &SearchResult{Status: SearchStatusNoResults}
+	result.SelectRoom("302")
+	if result.Status != SearchStatusNoResults || result.Room != "302" {
+		t.Errorf("status = %q, room = %q", result.Status, result.Room)
+	}
+	if !errors.Is(result.Err(), ErrNoResults) {
+		t.Errorf("Err() = %v, want ErrNoResults", result.Err())
+	}
+}
+
+// TestNewPropertyListingFromDetailTable covers the detail-style page
+// fallback, whose fields use the table labels mapped by the in-page script
+func TestNewPropertyListingFromDetailTable(t *testing.T) {
+	fields := map[string]string{
+		"property_name":  "クレール住吉",
+		"room_number":    "302号室",
+		"rent":           "7.7万円",
+		"management_fee": "5,000円",
+		"address":        "大阪府大阪市住吉区長居1-2-3",
+	}
+	listing := newPropertyListing(fields)
+	if listing.Name != "クレール住吉" || listing.RoomNumber != "302号室" || listing.Rent != 77000 || listing.ManagementFee != 5000 {
+		t.Fatalf("listing = %+v", listing)
+	}
+
+	result := &SearchResult{Status: SearchStatusFound, Fields: fields, Listings: []PropertyListing{listing}}
+	result.SelectBuilding(MatchTarget{Name: "クレール住吉"})
+	if result.Status != SearchStatusFound || result.Match == nil || result.Match.Score != 1 {
+		t.Errorf("status = %s, match = %+v, want the page's building matched", result.Status, result.Match)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"log"
 	"os"
 	"time"
@@ -13,21 +12,16 @@ func quickTestFunc() {
 	log.Println("=== Quick Modal Test ===")
 
 	// Create a simple browser context
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath("/Applications/Chromium.app/Contents/MacOS/Chromium"),
-		chromedp.Flag("headless", false),
-	)
-
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
-
-	ctx, cancel := chromedp.NewContext(allocCtx)
+	ctx, cancel, err := newBrowserContext(DefaultBrowserConfig())
+	if err != nil {
+		log.Fatal("Failed to start browser:", err)
+	}
 	defer cancel()
 
 	// Navigate directly to search page to see the modal
 	log.Println("Navigating directly to search page...")
-	err := chromedp.Run(ctx,
-		chromedp.Navigate("https://itandibb.com/rent_rooms/list"),
+	err = chromedp.Run(ctx,
+		chromedp.Navigate(DefaultSiteConfig().RentListURL()),
 		chromedp.WaitReady("body"),
 	)
 	if err != nil {
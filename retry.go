@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Duration is a time.Duration written as "2s" or "500ms" in JSON config files
+type Duration time.Duration
+
+// MarshalJSON writes the duration in time.Duration notation
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts "2s"-style strings and plain seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var seconds float64
+		if err := json.Unmarshal(data, &seconds); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// RetryPolicy controls how often a failed scraping step is retried and how
+// long to back off in between
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries including the first; 1 disables retries
+	MaxAttempts int `json:"max_attempts"`
+
+	// InitialBackoff is the wait before the second attempt
+	InitialBackoff Duration `json:"initial_backoff"`
+
+	// MaxBackoff caps the exponentially growing wait
+	MaxBackoff Duration `json:"max_backoff"`
+
+	// Multiplier grows the wait after every attempt
+	Multiplier float64 `json:"multiplier"`
+
+	// Jitter randomizes each wait by up to this fraction (0.2 = ±20%)
+	Jitter float64 `json:"jitter"`
+
+	// RetryOn lists the error codes (see ErrorCode) worth retrying
+	RetryOn []string `json:"retry_on"`
+}
+
+// DefaultRetryPolicy retries transient failures three times. Rejected
+// credentials, phone verification, missing selectors and empty results are
+// not retried because another attempt would fail the same way.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: Duration(2 * time.Second),
+		MaxBackoff:     Duration(30 * time.Second),
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryOn:        []string{"timeout", "session_expired", "modal_blocking", "navigation_failed", "extraction_failed"},
+	}
+}
+
+// LoadRetryPolicy reads a JSON retry policy on top of the defaults
+func LoadRetryPolicy(path string) (RetryPolicy, error) {
+	p := DefaultRetryPolicy()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, fmt.Errorf("failed to read retry config: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("failed to parse retry config %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return p, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Validate checks the attempt count, backoff values and error codes
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("backoff must not be negative")
+	}
+	if p.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1, got %g", p.Multiplier)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %g", p.Jitter)
+	}
+	for _, code := range p.RetryOn {
+		if !knownErrorCode(code) {
+			return fmt.Errorf("unknown error code %q in retry_on", code)
+		}
+	}
+	return nil
+}
+
+// Retryable reports whether err belongs to one of the RetryOn classes.
+// Cancellation is never retried.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return slices.Contains(p.RetryOn, ErrorCode(err))
+}
+
+// Backoff returns the wait after the given failed attempt (1-based)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	wait := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		wait *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// Do runs op until it succeeds, fails with a non-retryable error or runs out
+// of attempts. Between attempts it backs off and calls recover, if set, to
+// bring the page back into a state where op can start over.
+func (p RetryPolicy) Do(ctx context.Context, step string, op func() error, recover func(err error) error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if !p.Retryable(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			if attempt > 1 {
+				return fmt.Errorf("%s: giving up after %d attempts: %w", step, attempt, err)
+			}
+			return err
+		}
+
+		wait := p.Backoff(attempt)
+		log.Printf("%s: attempt %d/%d failed (%s): %v - retrying in %s\n",
+			step, attempt, p.MaxAttempts, ErrorCode(err), err, wait.Round(10*time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		if recover != nil {
+			if rerr := recover(err); rerr != nil {
+				log.Printf("%s: recovery before attempt %d failed: %v\n", step, attempt+1, rerr)
+			}
+		}
+	}
+}
+
+// knownErrorCode reports whether code is one of the codes returned by ErrorCode
+func knownErrorCode(code string) bool {
+	if code == "unknown" {
+		return true
+	}
+	for _, c := range errorCodes {
+		if c.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// UseRetryPolicy replaces the default retry policy
+func (s *ITANDIScraper) UseRetryPolicy(policy RetryPolicy) {
+	s.retry = policy
+}
+
+// recoverStep prepares the page for another attempt: a session that expired
+// is logged in again, anything else is reloaded
+func (s *ITANDIScraper) recoverStep(err error) error {
+	if errors.Is(err, ErrSessionExpired) {
+		return s.relogin()
+	}
+	return s.reloadPage()
+}
+
+// reloadPage reloads the current page and waits for it to settle
+func (s *ITANDIScraper) reloadPage() error {
+	log.Println("Reloading page before retrying...")
+	if err := chromedp.Run(s.ctx, chromedp.Reload()); err != nil {
+		return fmt.Errorf("%w: reload: %w", ErrNavigationFailed, err)
+	}
+	return s.waitPageSettled("reload")
+}
+
+// relogin signs in again after ITANDI BB dropped the session
+func (s *ITANDIScraper) relogin() error {
+	log.Println("Session expired - logging in again before retrying...")
+	if err := s.navigateToLogin(); err != nil {
+		return err
+	}
+	if err := s.login(); err != nil {
+		return err
+	}
+	if s.session.File != "" {
+		if err := s.SaveSession(s.session.File); err != nil {
+			log.Printf("Warning: Failed to save session: %v\n", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: Duration(time.Second),
+		MaxBackoff:     Duration(5 * time.Second),
+		Multiplier:     2,
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.Backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Backoff(1) with jitter = %s, want within 0.5s-1.5s", got)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, Multiplier: 1, RetryOn: []string{"timeout", "session_expired"}}
+
+	tests := []struct {
+		name        string
+		errs        []error
+		wantCalls   int
+		wantErr     error
+		wantRecover int
+	}{
+		{"success first", []error{nil}, 1, nil, 0},
+		{"transient then success", []error{ErrTimeout, nil}, 2, nil, 1},
+		{"not retryable", []error{ErrLoginRejected}, 1, ErrLoginRejected, 0},
+		{"exhausted", []error{ErrSessionExpired, ErrTimeout, ErrTimeout}, 3, ErrTimeout, 2},
+		{"cancelled", []error{fmt.Errorf("%w: %w", ErrTimeout, context.Canceled)}, 1, context.Canceled, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, recovered := 0, 0
+			err := p.Do(context.Background(), "test", func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			}, func(error) error {
+				recovered++
+				return nil
+			})
+
+			if calls != tt.wantCalls || recovered != tt.wantRecover {
+				t.Errorf("calls = %d, recovered = %d, want %d, %d", calls, recovered, tt.wantCalls, tt.wantRecover)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJSON(t *testing.T) {
+	p := DefaultRetryPolicy()
+	data := []byte(`{"max_attempts": 5, "initial_backoff": "500ms", "max_backoff": 10, "retry_on": ["timeout", "unknown"]}`)
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if p.MaxAttempts != 5 || time.Duration(p.InitialBackoff) != 500*time.Millisecond || time.Duration(p.MaxBackoff) != 10*time.Second {
+		t.Errorf("policy = %+v", p)
+	}
+	if !p.Retryable(errors.New("net::ERR_CONNECTION_RESET")) {
+		t.Error("unknown errors should be retryable when listed")
+	}
+
+	p.RetryOn = []string{"timeout", "flaky"}
+	if err := p.Validate(); err == nil {
+		t.Error("Validate accepted an unknown error code")
+	}
+}
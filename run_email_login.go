@@ -5,11 +5,11 @@ import (
 	"time"
 )
 
-func runEmailLogin() {
+func runEmailLogin(cfg BrowserConfig, site SiteConfig) {
 	log.Println("=== ITANDI BB Email/Password Login ===")
 
 	// Create email login scraper
-	scraper, err := NewEmailLoginScraper(false) // Use visible browser
+	scraper, err := NewEmailLoginScraperWithConfig(cfg, site)
 	if err != nil {
 		log.Fatal("Failed to create email scraper:", err)
 	}
@@ -30,7 +30,7 @@ func runEmailLogin() {
 		log.Printf("Current URL: %s\n", url)
 		
 		log.Println("\nNote: ITANDI BB appears to use phone verification instead of email/password login")
-		log.Println("Please use the -updated flag for the phone verification system")
+		log.Println("Please use \"crm analyze phone\" for the phone verification system")
 		return
 	}
 
@@ -63,7 +63,7 @@ func runEmailLogin() {
 	log.Printf("After login URL: %s\n", url)
 	
 	// Check if we're on a different page (indicating successful login)
-	if url != "https://itandi-accounts.com/" && url != "https://itandi-accounts.com/login" {
+	if !site.IsLoginPage(url) {
 		log.Println("✅ Login appears successful - redirected to new page")
 		
 		// Step 4: Try to search for property
@@ -88,7 +88,9 @@ func runEmailLogin() {
 	log.Println("- email_login_success.png") 
 	log.Println("- email_login_dashboard.png")
 	
-	// Keep browser open for inspection
-	log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
-	time.Sleep(30 * time.Second)
+	// Keep browser open for inspection if not headless
+	if !cfg.Headless {
+		log.Println("\nKeeping browser open for 30 seconds for manual inspection...")
+		time.Sleep(30 * time.Second)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// WatchList は定期確認する物件の一覧と実行条件
+type WatchList struct {
+	// Timezone the cron expressions and business hours are read in; default Asia/Tokyo
+	Timezone string `json:"timezone"`
+
+	// BusinessHours, when set, skips runs outside the office's hours
+	BusinessHours *BusinessHours `json:"business_hours"`
+
+	// SkipHolidays skips runs on Japanese public holidays
+	SkipHolidays bool `json:"skip_holidays"`
+
+	// Holidays are extra closed days (YYYY-MM-DD), e.g. 年末年始
+	Holidays []string `json:"holidays"`
+
+	Watch []WatchItem `json:"watch"`
+}
+
+// WatchItem is one property confirmed on a schedule
+type WatchItem struct {
+	PropertyName string `json:"property_name"`
+	RoomNumber   string `json:"room_number,omitempty"`
+
+	// Address and ManagementCompany help pick the building among similarly named results
+	Address           string `json:"address,omitempty"`
+	ManagementCompany string `json:"management_company,omitempty"`
+
+	// Schedule is a 5-field cron expression such as "0 9,15 * * 1-5"
+	Schedule string `json:"schedule"`
+}
+
+// BusinessHours is the daily window in which scheduled runs may start
+type BusinessHours struct {
+	Start string `json:"start"` // "09:00"
+	End   string `json:"end"`   // "18:00"
+
+	// Days are the open weekdays ("mon".."sun"); empty means every day
+	Days []string `json:"days"`
+}
+
+// weekdayNames maps BusinessHours.Days to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// LoadWatchList reads and validates a JSON watch list
+func LoadWatchList(path string) (WatchList, error) {
+	var list WatchList
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return list, fmt.Errorf("failed to read watch list: %w", err)
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return list, fmt.Errorf("failed to parse watch list %s: %w", path, err)
+	}
+	if _, err := NewScheduler(list, nil); err != nil {
+		return list, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Scheduler runs the confirmations of a watch list when their cron
+// expressions fire. A property whose previous run is still going is skipped
+// rather than queued twice.
+type Scheduler struct {
+	loc     *time.Location
+	open    func(time.Time) (bool, string)
+	entries []*scheduleEntry
+	confirm confirmFunc
+	wg      sync.WaitGroup
+}
+
+// scheduleEntry is a watch item with its parsed schedule
+type scheduleEntry struct {
+	WatchItem
+	schedule cron.Schedule
+	next     time.Time
+
+	mu      sync.Mutex
+	running bool
+}
+
+// NewScheduler parses the watch list; confirm runs one confirmation
+func NewScheduler(list WatchList, confirm confirmFunc) (*Scheduler, error) {
+	if len(list.Watch) == 0 {
+		return nil, errors.New("watch list is empty")
+	}
+	loc, err := time.LoadLocation(orDefault(list.Timezone, "Asia/Tokyo"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	open, err := list.openFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scheduler{loc: loc, open: open, confirm: confirm}
+	for i, item := range list.Watch {
+		if strings.TrimSpace(item.PropertyName) == "" {
+			return nil, fmt.Errorf("watch %d: property_name is required", i+1)
+		}
+		schedule, err := cron.ParseStandard(item.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("watch %d (%s): invalid schedule %q: %w", i+1, item.PropertyName, item.Schedule, err)
+		}
+		s.entries = append(s.entries, &scheduleEntry{WatchItem: item, schedule: schedule})
+	}
+	return s, nil
+}
+
+// openFunc builds the check for business hours and holidays
+func (l WatchList) openFunc() (func(time.Time) (bool, string), error) {
+	var start, end time.Duration
+	days := make(map[time.Weekday]bool)
+	if bh := l.BusinessHours; bh != nil {
+		var err error
+		if start, err = parseClock(bh.Start); err != nil {
+			return nil, fmt.Errorf("business_hours.start: %w", err)
+		}
+		if end, err = parseClock(bh.End); err != nil {
+			return nil, fmt.Errorf("business_hours.end: %w", err)
+		}
+		if end <= start {
+			return nil, fmt.Errorf("business_hours.end %s must be after start %s", bh.End, bh.Start)
+		}
+		for _, d := range bh.Days {
+			wd, ok := weekdayNames[strings.ToLower(d)]
+			if !ok {
+				return nil, fmt.Errorf("business_hours.days: unknown day %q", d)
+			}
+			days[wd] = true
+		}
+	}
+
+	closed := make(map[string]bool, len(l.Holidays))
+	for _, d := range l.Holidays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("holidays: invalid date %q", d)
+		}
+		closed[d] = true
+	}
+
+	return func(t time.Time) (bool, string) {
+		if closed[t.Format("2006-01-02")] {
+			return false, "closed day"
+		}
+		if l.SkipHolidays {
+			if name := japaneseHolidayName(t); name != "" {
+				return false, name
+			}
+		}
+		if l.BusinessHours == nil {
+			return true, ""
+		}
+		if len(days) > 0 && !days[t.Weekday()] {
+			return false, "closed weekday"
+		}
+		y, m, d := t.Date()
+		clock := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+		if clock < start || clock >= end {
+			return false, "outside business hours"
+		}
+		return true, ""
+	}, nil
+}
+
+// parseClock parses "09:30" as the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Run fires the schedules until ctx is cancelled, then waits for running
+// confirmations to finish
+func (s *Scheduler) Run(ctx context.Context) {
+	now := time.Now().In(s.loc)
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+		log.Printf("Scheduled %s %s (%s): next run %s\n", e.PropertyName, e.RoomNumber, e.Schedule, e.next.Format("2006-01-02 15:04"))
+	}
+
+	for {
+		next := s.entries[0].next
+		for _, e := range s.entries[1:] {
+			if e.next.Before(next) {
+				next = e.next
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.wg.Wait()
+			return
+		case <-timer.C:
+		}
+		s.dispatch(ctx, time.Now().In(s.loc))
+	}
+}
+
+// dispatch starts every entry due at now and schedules its next run
+func (s *Scheduler) dispatch(ctx context.Context, now time.Time) {
+	for _, e := range s.entries {
+		if e.next.After(now) {
+			continue
+		}
+		due := e.next
+		e.next = e.schedule.Next(now)
+
+		if open, reason := s.open(due); !open {
+			log.Printf("Skipping %s %s at %s: %s\n", e.PropertyName, e.RoomNumber, due.Format("2006-01-02 15:04"), reason)
+			continue
+		}
+		if !e.start() {
+			log.Printf("Skipping %s %s at %s: previous run still in progress\n", e.PropertyName, e.RoomNumber, due.Format("2006-01-02 15:04"))
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer e.finish()
+			if ctx.Err() != nil {
+				return
+			}
+			res := s.confirm(BatchItem{
+				PropertyName:      e.PropertyName,
+				RoomNumber:        e.RoomNumber,
+				Address:           e.Address,
+				ManagementCompany: e.ManagementCompany,
+			})
+			log.Printf("Scheduled confirmation %s %s: %s %s\n", e.PropertyName, e.RoomNumber, res.Status, res.ErrorCode)
+		}()
+	}
+}
+
+// start marks the entry running, reporting false when it already is
+func (e *scheduleEntry) start() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running {
+		return false
+	}
+	e.running = true
+	return true
+}
+
+func (e *scheduleEntry) finish() {
+	e.mu.Lock()
+	e.running = false
+	e.mu.Unlock()
+}
+
+// runScheduler confirms the properties of the watch list on their schedules
+// through one logged-in browser pool until interrupted
+func runScheduler(list WatchList, browserCfg BrowserConfig, site SiteConfig, session SessionOptions, opts PoolOptions, history *HistoryStore, notifier *Notifier) {
+	log.Println("=== ITANDI BB Scheduled Confirmation ===")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := NewBrowserPool(browserCfg, site, session, opts)
+	if err != nil {
+		log.Fatal("Failed to start browser pool:", err)
+	}
+	defer pool.Close()
+
+	scheduler, err := NewScheduler(list, func(item BatchItem) BatchResult {
+		res := pool.Confirm(ctx, item)
+		recordHistory(history, &res, SourceSchedule)
+		notifier.Notify(ctx, res)
+		return res
+	})
+	if err != nil {
+		log.Fatal("Invalid watch list:", err)
+	}
+
+	scheduler.Run(ctx)
+	log.Println("Scheduler stopped")
+}
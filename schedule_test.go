@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJapaneseHolidayName(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2026-01-01", "元日"},
+		{"2026-01-12", "成人の日"},
+		{"2026-03-20", "春分の日"},
+		{"2026-05-06", "振替休日"}, // 5/3 is a Sunday
+		{"2026-07-20", "海の日"},
+		{"2026-09-21", "敬老の日"},
+		{"2026-09-22", "国民の休日"},
+		{"2026-09-23", "秋分の日"},
+		{"2026-10-12", "スポーツの日"},
+		{"2026-11-23", "勤労感謝の日"},
+		{"2025-02-24", "振替休日"},
+		{"2021-07-23", "スポーツの日"},
+		{"2021-08-09", "振替休日"}, // 山の日 moved to Sunday 8/8
+		{"2021-10-11", ""},
+		{"2026-05-07", ""},
+		{"2026-12-23", ""},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := japaneseHolidayName(date); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestWatchListOpen(t *testing.T) {
+	list := WatchList{
+		BusinessHours: &BusinessHours{Start: "09:00", End: "18:00", Days: []string{"mon", "tue", "thu", "fri", "sat"}},
+		SkipHolidays:  true,
+		Holidays:      []string{"2026-12-29"},
+	}
+	open, err := list.openFunc()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jst := time.FixedZone("JST", 9*3600)
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 4, 6, 9, 0, 0, 0, jst), true},     // Monday
+		{time.Date(2026, 4, 6, 8, 59, 0, 0, jst), false},   // before opening
+		{time.Date(2026, 4, 6, 18, 0, 0, 0, jst), false},   // closing time
+		{time.Date(2026, 4, 8, 10, 0, 0, 0, jst), false},   // Wednesday
+		{time.Date(2026, 9, 22, 10, 0, 0, 0, jst), false},  // 国民の休日
+		{time.Date(2026, 12, 29, 10, 0, 0, 0, jst), false}, // company holiday
+	}
+	for _, tt := range tests {
+		if got, reason := open(tt.at); got != tt.want {
+			t.Errorf("%s: open = %v (%s), want %v", tt.at, got, reason, tt.want)
+		}
+	}
+}
+
+func TestNewSchedulerRejectsInvalidWatchList(t *testing.T) {
+	for _, list := range []WatchList{
+		{},
+		{Watch: []WatchItem{{PropertyName: "クレール住吉", Schedule: "every morning"}}},
+		{Watch: []WatchItem{{Schedule: "0 9 * * *"}}},
+		{Timezone: "Mars/Olympus", Watch: []WatchItem{{PropertyName: "クレール住吉", Schedule: "0 9 * * *"}}},
+		{BusinessHours: &BusinessHours{Start: "18:00", End: "09:00"}, Watch: []WatchItem{{PropertyName: "クレール住吉", Schedule: "0 9 * * *"}}},
+	} {
+		if _, err := NewScheduler(list, nil); err == nil {
+			t.Errorf("%+v: expected an error", list)
+		}
+	}
+}
+
+func TestSchedulerDispatchSkipsOverlapsAndClosedTimes(t *testing.T) {
+	release := make(chan struct{})
+	var runs atomic.Int32
+	s, err := NewScheduler(WatchList{
+		BusinessHours: &BusinessHours{Start: "09:00", End: "18:00"},
+		Watch:         []WatchItem{{PropertyName: "クレール住吉", Schedule: "0 * * * *"}},
+	}, func(item BatchItem) BatchResult {
+		runs.Add(1)
+		<-release
+		return BatchResult{BatchItem: item, Status: BatchStatusFound}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := s.entries[0]
+	at := time.Date(2026, 4, 6, 9, 0, 0, 0, s.loc)
+
+	e.next = at
+	s.dispatch(context.Background(), at)
+	if !e.next.Equal(at.Add(time.Hour)) {
+		t.Errorf("next = %s, want 10:00", e.next)
+	}
+
+	// The 9:00 run is still in progress at 10:00
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	s.dispatch(context.Background(), at.Add(time.Hour))
+
+	// 19:00 is outside business hours
+	close(release)
+	s.wg.Wait()
+	e.next = at.Add(10 * time.Hour)
+	s.dispatch(context.Background(), e.next)
+	s.wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("confirm ran %d times, want 1", n)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// selectorConfigVersion is the config format version this build understands
+const selectorConfigVersion = 1
+
+// defaultSelectorsJSON is the selectors.json shipped with the source and used when no config is given
+//
+//go:embed selectors.json
+var defaultSelectorsJSON []byte
+
+// requiredSelectorFields must be present with at least one selector
+var requiredSelectorFields = []string{
+	"login.link",
+	"login.email",
+	"login.password",
+	"login.submit",
+	"search.list_button",
+	"search.property_name_input",
+	"search.submit_button",
+	"modal.close",
+	"modal.close_all",
+}
+
+// SelectorConfig はセレクタ設定ファイルの内容
+type SelectorConfig struct {
+	Version int `json:"version"`
+
+	// Selectors maps a logical field (e.g. "login.email") to selectors tried in order
+	Selectors map[string][]string `json:"selectors"`
+}
+
+// ParseSelectorConfig parses and validates selector config JSON
+func ParseSelectorConfig(data []byte) (*SelectorConfig, error) {
+	var cfg SelectorConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse selector config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// LoadSelectorConfig reads and validates a selector config file
+func LoadSelectorConfig(path string) (*SelectorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read selector config: %w", err)
+	}
+	cfg, err := ParseSelectorConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// DefaultSelectorConfig returns the built-in selectors
+func DefaultSelectorConfig() *SelectorConfig {
+	cfg, err := ParseSelectorConfig(defaultSelectorsJSON)
+	if err != nil {
+		panic(fmt.Sprintf("built-in selectors.json is invalid: %v", err))
+	}
+	return cfg
+}
+
+// Validate checks the version, required fields and basic selector syntax
+func (c *SelectorConfig) Validate() error {
+	if c.Version != selectorConfigVersion {
+		return fmt.Errorf("unsupported selector config version %d (expected %d)", c.Version, selectorConfigVersion)
+	}
+
+	var problems []string
+	for _, field := range requiredSelectorFields {
+		if len(c.Selectors[field]) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no selectors", field))
+		}
+	}
+
+	fields := make([]string, 0, len(c.Selectors))
+	for field := range c.Selectors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for i, selector := range c.Selectors[field] {
+			if err := checkSelectorSyntax(selector); err != nil {
+				problems = append(problems, fmt.Sprintf("%s[%d]: %v", field, i, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid selector config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Get returns the ordered selectors for a logical field
+func (c *SelectorConfig) Get(field string) []string {
+	return c.Selectors[field]
+}
+
+// Prefixed returns the fields under prefix (e.g. "details.") keyed by the remaining name
+func (c *SelectorConfig) Prefixed(prefix string) map[string][]string {
+	fields := make(map[string][]string)
+	for field, selectors := range c.Selectors {
+		if name, ok := strings.CutPrefix(field, prefix); ok {
+			fields[name] = selectors
+		}
+	}
+	return fields
+}
+
+// checkSelectorSyntax catches empty locators and unbalanced brackets or quotes
+func checkSelectorSyntax(selector string) error {
+	locator := ParseLocator(selector)
+	if locator.Query == "" {
+		return fmt.Errorf("empty selector")
+	}
+	// Text and label queries are plain strings
+	if locator.Kind == LocatorText || locator.Kind == LocatorLabel {
+		return nil
+	}
+
+	var stack []rune
+	var quote rune
+	for _, r := range selector {
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '"', '\'':
+			quote = r
+		case '(', '[':
+			stack = append(stack, r)
+		case ')', ']':
+			open := '('
+			if r == ']' {
+				open = '['
+			}
+			if len(stack) == 0 || stack[len(stack)-1] != open {
+				return fmt.Errorf("unbalanced %q in %q", r, selector)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("unterminated quote in %q", selector)
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q in %q", stack[len(stack)-1], selector)
+	}
+	return nil
+}
+
+// SelectorStore holds the active selector config and can reload it from disk
+type SelectorStore struct {
+	mu      sync.RWMutex
+	cfg     *SelectorConfig
+	path    string
+	modTime time.Time
+}
+
+// NewSelectorStore creates a store serving the built-in selectors
+func NewSelectorStore() *SelectorStore {
+	return &SelectorStore{cfg: DefaultSelectorConfig()}
+}
+
+// LoadSelectorStore creates a store backed by a config file
+func LoadSelectorStore(path string) (*SelectorStore, error) {
+	store := &SelectorStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Config returns the active config
+func (s *SelectorStore) Config() *SelectorConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Get returns the ordered selectors for a logical field
+func (s *SelectorStore) Get(field string) []string {
+	return s.Config().Get(field)
+}
+
+// Reload re-reads the config file. The previous config stays active when the new one is invalid.
+func (s *SelectorStore) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat selector config: %w", err)
+	}
+	cfg, err := LoadSelectorConfig(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cfg = cfg
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	log.Printf("Loaded selector config %s (version %d, %d fields)\n", s.path, cfg.Version, len(cfg.Selectors))
+	return nil
+}
+
+// Watch polls the config file and reloads it whenever it changes, until ctx is done
+func (s *SelectorStore) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(s.path)
+				if err != nil {
+					continue
+				}
+				s.mu.RLock()
+				changed := info.ModTime().After(s.modTime)
+				s.mu.RUnlock()
+				if !changed {
+					continue
+				}
+				if err := s.Reload(); err != nil {
+					log.Printf("Warning: Keeping previous selectors, reload failed: %v\n", err)
+					s.mu.Lock()
+					s.modTime = info.ModTime()
+					s.mu.Unlock()
+				}
+			}
+		}
+	}()
+}
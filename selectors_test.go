@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selectorConfigJSON returns a valid config with every required field, with
+// overrides applied (a nil value deletes the field)
+func selectorConfigJSON(t *testing.T, version int, overrides map[string][]string) []byte {
+	t.Helper()
+	selectors := make(map[string][]string)
+	for _, field := range requiredSelectorFields {
+		selectors[field] = []string{"#" + strings.ReplaceAll(field, ".", "-")}
+	}
+	for field, values := range overrides {
+		if values == nil {
+			delete(selectors, field)
+			continue
+		}
+		selectors[field] = values
+	}
+	data, err := json.Marshal(SelectorConfig{Version: version, Selectors: selectors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDefaultSelectorConfig(t *testing.T) {
+	cfg := DefaultSelectorConfig()
+	for _, field := range requiredSelectorFields {
+		if len(cfg.Get(field)) == 0 {
+			t.Errorf("built-in config has no selectors for %s", field)
+		}
+	}
+	if len(cfg.Prefixed("details.")) == 0 {
+		t.Error("built-in config has no details.* fields")
+	}
+}
+
+func TestParseSelectorConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"valid", selectorConfigJSON(t, 1, nil), ""},
+		{"extra fields", selectorConfigJSON(t, 1, map[string][]string{"details.rent": {"text=賃料", "//dt[.='賃料']"}}), ""},
+		{"not JSON", []byte(`{"version": 1,`), "failed to parse selector config"},
+		{"wrong version", selectorConfigJSON(t, 2, nil), "unsupported selector config version 2"},
+		{"missing version", []byte(`{"selectors": {}}`), "unsupported selector config version 0"},
+		{"missing required field", selectorConfigJSON(t, 1, map[string][]string{"login.email": nil}), "login.email: no selectors"},
+		{"empty required field", selectorConfigJSON(t, 1, map[string][]string{"modal.close": {}}), "modal.close: no selectors"},
+		{"bad selector", selectorConfigJSON(t, 1, map[string][]string{"search.submit_button": {"button", "button[type=submit"}}), "search.submit_button[1]: unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseSelectorConfig(tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseSelectorConfig() = %v, want nil", err)
+				}
+				if cfg.Get("login.email")[0] != "#login-email" {
+					t.Errorf("login.email = %v", cfg.Get("login.email"))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSelectorConfig() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectorConfigValidateReportsEveryProblem(t *testing.T) {
+	cfg := &SelectorConfig{
+		Version: selectorConfigVersion,
+		Selectors: map[string][]string{
+			"details.b": {"div)"},
+			"details.a": {"", "text="},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted an invalid config")
+	}
+	msg := err.Error()
+	for _, field := range requiredSelectorFields {
+		if !strings.Contains(msg, field+": no selectors") {
+			t.Errorf("error does not report missing %s:\n%s", field, msg)
+		}
+	}
+	// Syntax problems are listed in field order
+	a0, a1, b0 := strings.Index(msg, "details.a[0]"), strings.Index(msg, "details.a[1]"), strings.Index(msg, "details.b[0]")
+	if a0 < 0 || a1 < 0 || b0 < 0 || !(a0 < a1 && a1 < b0) {
+		t.Errorf("syntax problems missing or out of order:\n%s", msg)
+	}
+}
+
+func TestCheckSelectorSyntax(t *testing.T) {
+	tests := []struct {
+		selector string
+		wantErr  string
+	}{
+		{`input[name="email"]`, ""},
+		{`button:has(span[class*="x"])`, ""},
+		{`input[placeholder="[メール]"]`, ""},
+		{`a[title='it"s']`, ""},
+		{`//div[@class='a'][1]`, ""},
+		{`text=賃料 (税込`, ""},
+		{`label=[必須] メール`, ""},
+		{`role=button[name="ログイン"]`, ""},
+		{``, "empty selector"},
+		{`   `, "empty selector"},
+		{`css=`, "empty selector"},
+		{`input[name="email"`, "unclosed '['"},
+		{`div)`, "unbalanced ')'"},
+		{`div:not(.a]`, "unbalanced ']'"},
+		{`input[name="email]`, "unterminated quote"},
+		{`xpath=//div[@id='x'`, "unclosed '['"},
+	}
+	for _, tt := range tests {
+		err := checkSelectorSyntax(tt.selector)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkSelectorSyntax(%q) = %v, want nil", tt.selector, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkSelectorSyntax(%q) = %v, want error containing %q", tt.selector, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectorStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	if err := os.WriteFile(path, selectorConfigJSON(t, 1, nil), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadSelectorStore(path)
+	if err != nil {
+		t.Fatalf("LoadSelectorStore: %v", err)
+	}
+	if got := store.Get("login.email"); !slices.Equal(got, []string{"#login-email"}) {
+		t.Errorf("login.email = %v", got)
+	}
+
+	updated := selectorConfigJSON(t, 1, map[string][]string{"login.email": {"#email", "input[type=email]"}})
+	if err := os.WriteFile(path, updated, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := store.Get("login.email"); !slices.Equal(got, []string{"#email", "input[type=email]"}) {
+		t.Errorf("after reload login.email = %v", got)
+	}
+
+	// An invalid file keeps the previous config
+	if err := os.WriteFile(path, selectorConfigJSON(t, 1, map[string][]string{"login.email": nil}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Error("Reload accepted an invalid config")
+	}
+	if got := store.Get("login.email"); !slices.Equal(got, []string{"#email", "input[type=email]"}) {
+		t.Errorf("after failed reload login.email = %v", got)
+	}
+
+	if _, err := LoadSelectorStore(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadSelectorStore accepted a missing file")
+	}
+}
+
+func TestSelectorStoreWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	if err := os.WriteFile(path, selectorConfigJSON(t, 1, nil), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadSelectorStore(path)
+	if err != nil {
+		t.Fatalf("LoadSelectorStore: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store.Watch(ctx, 10*time.Millisecond)
+
+	// writeAndWait rewrites the file with a later modification time and
+	// waits for the watcher to serve want for login.email
+	writeAndWait := func(data []byte, want []string, step time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		future := time.Now().Add(step)
+		if err := os.Chtimes(path, future, future); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for !slices.Equal(store.Get("login.email"), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("login.email = %v, want %v", store.Get("login.email"), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	writeAndWait(selectorConfigJSON(t, 1, map[string][]string{"login.email": {"#watched"}}), []string{"#watched"}, time.Minute)
+
+	// A broken edit is skipped and the next valid one is still picked up
+	if err := os.WriteFile(path, []byte(`{"version": 1,`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := time.Now().Add(2 * time.Minute)
+	if err := os.Chtimes(path, broken, broken); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := store.Get("login.email"); !slices.Equal(got, []string{"#watched"}) {
+		t.Errorf("after broken edit login.email = %v", got)
+	}
+	writeAndWait(selectorConfigJSON(t, 1, map[string][]string{"login.email": {"#fixed"}}), []string{"#fixed"}, 3*time.Minute)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Confirmation job statuses
+const (
+	ConfirmationQueued    = "queued"
+	ConfirmationRunning   = "running"
+	ConfirmationCompleted = "completed"
+)
+
+// confirmationRetention is how long finished confirmations stay queryable
+const confirmationRetention = time.Hour
+
+// ConfirmationRequest is the body of POST /confirmations
+type ConfirmationRequest struct {
+	PropertyName      string `json:"property_name"`
+	RoomNumber        string `json:"room_number,omitempty"`
+	Address           string `json:"address,omitempty"`
+	ManagementCompany string `json:"management_company,omitempty"`
+}
+
+// Confirmation は1件の物件確認ジョブ
+type Confirmation struct {
+	ID         string              `json:"id"`
+	Request    ConfirmationRequest `json:"request"`
+	Status     string              `json:"status"`
+	Result     *BatchResult        `json:"result,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+}
+
+// confirmFunc confirms one property on a logged-in scraper
+type confirmFunc func(item BatchItem) BatchResult
+
+// ConfirmationServer queues confirmation requests for warm, logged-in
+// scrapers and serves their status over HTTP
+type ConfirmationServer struct {
+	mu      sync.Mutex
+	jobs    map[string]*Confirmation
+	queue   chan *Confirmation
+	workers int
+
+	// poolStats, when set, adds the browser pool's state to /healthz
+	poolStats func() PoolStats
+}
+
+// NewConfirmationServer creates a server that holds up to queueSize waiting requests
+func NewConfirmationServer(queueSize int) *ConfirmationServer {
+	return &ConfirmationServer{
+		jobs:  make(map[string]*Confirmation),
+		queue: make(chan *Confirmation, queueSize),
+	}
+}
+
+// Handler returns the HTTP API
+func (s *ConfirmationServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /confirmations", s.handleCreate)
+	mux.HandleFunc("GET /confirmations/{id}", s.handleGet)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	return mux
+}
+
+// UsePoolStats reports the browser pool's workers on /healthz
+func (s *ConfirmationServer) UsePoolStats(stats func() PoolStats) {
+	s.poolStats = stats
+}
+
+// Work confirms queued requests one at a time until ctx is cancelled.
+// Each warm scraper runs its own Work loop.
+func (s *ConfirmationServer) Work(ctx context.Context, confirm confirmFunc) {
+	s.mu.Lock()
+	s.workers++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.workers--
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.run(job, confirm)
+		}
+	}
+}
+
+// run confirms one job and records its result
+func (s *ConfirmationServer) run(job *Confirmation, confirm confirmFunc) {
+	started := time.Now()
+	s.mu.Lock()
+	job.Status = ConfirmationRunning
+	job.StartedAt = &started
+	s.mu.Unlock()
+
+	log.Printf("Confirmation %s: %s %s\n", job.ID, job.Request.PropertyName, job.Request.RoomNumber)
+	res := confirm(BatchItem{
+		PropertyName:      job.Request.PropertyName,
+		RoomNumber:        job.Request.RoomNumber,
+		Address:           job.Request.Address,
+		ManagementCompany: job.Request.ManagementCompany,
+	})
+	log.Printf("Confirmation %s: %s %s\n", job.ID, res.Status, res.ErrorCode)
+
+	finished := time.Now()
+	s.mu.Lock()
+	job.Status = ConfirmationCompleted
+	job.Result = &res
+	job.FinishedAt = &finished
+	s.pruneLocked(finished)
+	s.mu.Unlock()
+}
+
+// pruneLocked forgets confirmations that finished longer than confirmationRetention ago
+func (s *ConfirmationServer) pruneLocked(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > confirmationRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *ConfirmationServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	req.PropertyName = strings.TrimSpace(req.PropertyName)
+	req.RoomNumber = strings.TrimSpace(req.RoomNumber)
+	if req.PropertyName == "" {
+		writeJSONError(w, http.StatusBadRequest, "property_name is required")
+		return
+	}
+
+	id, err := newConfirmationID()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	job := &Confirmation{
+		ID:        id,
+		Request:   req,
+		Status:    ConfirmationQueued,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	select {
+	case s.queue <- job:
+		s.jobs[id] = job
+	default:
+		s.mu.Unlock()
+		writeJSONError(w, http.StatusServiceUnavailable, "confirmation queue is full")
+		return
+	}
+	snapshot := *job
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/confirmations/"+id)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *ConfirmationServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var snapshot Confirmation
+	if ok {
+		snapshot = *job
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "confirmation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *ConfirmationServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	workers := s.workers
+	s.mu.Unlock()
+
+	body := map[string]any{
+		"workers": workers,
+		"queued":  len(s.queue),
+	}
+	if s.poolStats != nil {
+		stats := s.poolStats()
+		body["pool"] = stats
+		workers = min(workers, stats.Ready)
+	}
+
+	status, code := "ok", http.StatusOK
+	if workers == 0 {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	body["status"] = status
+	writeJSON(w, code, body)
+}
+
+// newConfirmationID returns a random hex identifier
+func newConfirmationID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeJSONError writes {"error": message}
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// runServer starts a browser pool and serves confirmation requests on addr
+// until interrupted. The address is bound before any browser is started, so
+// a port in use fails fast; errors are returned after the pool is closed.
+func runServer(addr string, browserCfg BrowserConfig, site SiteConfig, session SessionOptions, opts PoolOptions, history *HistoryStore, notifier *Notifier) error {
+	log.Println("=== ITANDI BB Confirmation Server ===")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	defer ln.Close()
+
+	pool, err := NewBrowserPool(browserCfg, site, session, opts)
+	if err != nil {
+		return fmt.Errorf("failed to start browser pool: %w", err)
+	}
+	defer pool.Close()
+
+	srv := NewConfirmationServer(100)
+	srv.UsePoolStats(pool.Stats)
+	for i := 0; i < opts.Workers; i++ {
+		go srv.Work(ctx, func(item BatchItem) BatchResult {
+			res := pool.Confirm(ctx, item)
+			recordHistory(history, &res, SourceServer)
+			notifier.Notify(ctx, res)
+			return res
+		})
+	}
+
+	httpServer := &http.Server{Addr: addr, Handler: srv.Handler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s\n", ln.Addr())
+	if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	log.Println("Server stopped")
+	return nil
+}
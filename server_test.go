@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfirmationServer(t *testing.T) {
+	srv := NewConfirmationServer(10)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("healthz without workers = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Work(ctx, func(item BatchItem) BatchResult {
+		return BatchResult{BatchItem: item, Status: BatchStatusFound, ConfirmedAt: time.Now()}
+	})
+
+	resp, err = http.Post(ts.URL+"/confirmations", "application/json",
+		strings.NewReader(`{"property_name": " クレール住吉 ", "room_number": "302"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created Confirmation
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if created.ID == "" || resp.Header.Get("Location") != "/confirmations/"+created.ID {
+		t.Fatalf("created = %+v, Location = %q", created, resp.Header.Get("Location"))
+	}
+
+	var got Confirmation
+	deadline := time.Now().Add(2 * time.Second)
+	for got.Status != ConfirmationCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("confirmation still %q", got.Status)
+		}
+		resp, err := http.Get(ts.URL + "/confirmations/" + created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got.Result == nil || got.Result.Status != BatchStatusFound || got.Result.PropertyName != "クレール住吉" || got.Result.RoomNumber != "302" {
+		t.Errorf("result = %+v", got.Result)
+	}
+
+	resp, err = http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz with a worker = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestConfirmationServerErrors(t *testing.T) {
+	srv := NewConfirmationServer(1)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/confirmations", `{"room_number": "302"}`, http.StatusBadRequest},
+		{"POST", "/confirmations", `not json`, http.StatusBadRequest},
+		{"GET", "/confirmations/unknown", "", http.StatusNotFound},
+		{"POST", "/confirmations", `{"property_name": "A"}`, http.StatusAccepted},
+		// No worker is running, so the second request finds the queue full
+		{"POST", "/confirmations", `{"property_name": "B"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s %s = %d, want %d", tt.method, tt.path, tt.body, resp.StatusCode, tt.want)
+		}
+	}
+}
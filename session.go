@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/crypto/scrypt"
+)
+
+// sessionKeyEnv holds the passphrase used to encrypt session files
+const sessionKeyEnv = "ITANDI_SESSION_KEY"
+
+// sessionFileMagic starts every session file; the version byte changes with the format
+const sessionFileMagic = "CRMSESS\x01"
+
+// sessionSaltSize is the length of the random per-file scrypt salt
+const sessionSaltSize = 16
+
+// scrypt cost parameters for deriving the session key (the recommended
+// interactive-login settings)
+const (
+	sessionScryptN = 1 << 15
+	sessionScryptR = 8
+	sessionScryptP = 1
+)
+
+// SessionOptions controls how a login session is reused across runs
+type SessionOptions struct {
+	// ProfileDir is a persistent Chromium user-data-dir. Cookies and
+	// localStorage survive in it between runs.
+	ProfileDir string
+
+	// File is an encrypted export of cookies and localStorage
+	File string
+}
+
+// savedSession is the plaintext content of a session file
+type savedSession struct {
+	SavedAt      time.Time                    `json:"saved_at"`
+	Cookies      []savedCookie                `json:"cookies"`
+	LocalStorage map[string]map[string]string `json:"local_storage"`
+}
+
+// savedCookie is the subset of a browser cookie needed to restore it
+type savedCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"http_only"`
+	Secure   bool    `json:"secure"`
+	Session  bool    `json:"session"`
+	SameSite string  `json:"same_site,omitempty"`
+}
+
+// IsSessionValid checks whether the browser is still logged in to ITANDI BB
+func (s *ITANDIScraper) IsSessionValid() (bool, error) {
+	log.Println("Checking whether the saved session is still valid...")
+
+	err := chromedp.Run(s.ctx,
+		chromedp.Navigate(s.site.TopURL()),
+		chromedp.WaitReady("body"),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to open top page: %w", err)
+	}
+
+	// An expired session redirects to the login page, possibly from script
+	if err := s.waitPageSettled("session check"); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+	currentURL, err := s.GetPageURL()
+	if err != nil {
+		return false, fmt.Errorf("failed to read page URL: %w", err)
+	}
+
+	var hasPasswordInput bool
+	err = chromedp.Run(s.ctx,
+		chromedp.EvaluateAsDevTools(`document.querySelector('input[type="password"]') !== null`, &hasPasswordInput),
+	)
+	if err != nil {
+		// Not knowing whether the login form is shown is not a valid session
+		return false, fmt.Errorf("failed to check for the login form: %w", err)
+	}
+
+	valid := s.site.IsTopPage(currentURL) && !hasPasswordInput
+	log.Printf("Session valid: %v (URL: %s)\n", valid, currentURL)
+	return valid, nil
+}
+
+// EnsureLoggedIn restores the saved session when it is still valid and
+// falls back to NavigateToLogin + Login when it has expired
+func (s *ITANDIScraper) EnsureLoggedIn() error {
+	if s.session.File != "" {
+		if err := s.LoadSession(s.session.File); err != nil {
+			log.Printf("Could not restore saved session: %v\n", err)
+		}
+	}
+
+	if s.session.File != "" || s.session.ProfileDir != "" {
+		valid, err := s.IsSessionValid()
+		if err != nil {
+			log.Printf("Session check failed: %v\n", err)
+		}
+		if valid {
+			log.Println("Reusing saved session - skipping login")
+			return nil
+		}
+		log.Println("Saved session has expired - logging in again")
+	}
+
+	if err := s.NavigateToLogin(); err != nil {
+		return err
+	}
+
+	if err := s.Login(); err != nil {
+		return err
+	}
+
+	if s.session.File != "" {
+		if err := s.SaveSession(s.session.File); err != nil {
+			log.Printf("Warning: Failed to save session: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// SaveSession exports cookies and localStorage of the current page to an encrypted file
+func (s *ITANDIScraper) SaveSession(path string) error {
+	passphrase, err := sessionPassphrase()
+	if err != nil {
+		return err
+	}
+
+	session := savedSession{
+		SavedAt:      time.Now(),
+		LocalStorage: make(map[string]map[string]string),
+	}
+
+	var cookies []*network.Cookie
+	err = chromedp.Run(s.ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = storage.GetCookies().Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read cookies: %w", err)
+	}
+	for _, c := range cookies {
+		session.Cookies = append(session.Cookies, savedCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			Session:  c.Session,
+			SameSite: c.SameSite.String(),
+		})
+	}
+
+	var origin string
+	var items map[string]string
+	err = chromedp.Run(s.ctx,
+		chromedp.Evaluate(`location.origin`, &origin),
+		chromedp.Evaluate(`Object.fromEntries(Object.entries(localStorage))`, &items),
+	)
+	if err != nil {
+		log.Printf("Warning: Failed to read localStorage: %v\n", err)
+	} else if len(items) > 0 {
+		session.LocalStorage[origin] = items
+	}
+
+	plaintext, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	ciphertext, err := encryptSession(passphrase, plaintext)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+
+	log.Printf("Session saved to %s (%d cookies)\n", path, len(session.Cookies))
+	return nil
+}
+
+// LoadSession imports cookies and localStorage from an encrypted session file
+func (s *ITANDIScraper) LoadSession(path string) error {
+	passphrase, err := sessionPassphrase()
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read session file: %w", err)
+	}
+	plaintext, err := decryptSession(passphrase, ciphertext)
+	if err != nil {
+		return err
+	}
+
+	var session savedSession
+	if err := json.Unmarshal(plaintext, &session); err != nil {
+		return fmt.Errorf("failed to decode session: %w", err)
+	}
+
+	var params []*network.CookieParam
+	for _, c := range session.Cookies {
+		param := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+		}
+		if c.SameSite != "" {
+			param.SameSite = network.CookieSameSite(c.SameSite)
+		}
+		if !c.Session && c.Expires > 0 {
+			expires := cdp.TimeSinceEpoch(time.Unix(int64(c.Expires), 0))
+			param.Expires = &expires
+		}
+		params = append(params, param)
+	}
+
+	err = chromedp.Run(s.ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return storage.SetCookies(params).Do(ctx)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to restore cookies: %w", err)
+	}
+
+	for origin, items := range session.LocalStorage {
+		data, err := json.Marshal(items)
+		if err != nil {
+			continue
+		}
+		err = chromedp.Run(s.ctx,
+			chromedp.Navigate(origin),
+			chromedp.WaitReady("body"),
+			chromedp.Evaluate(fmt.Sprintf(`
+				(() => {
+					const items = %s;
+					Object.entries(items).forEach(([k, v]) => localStorage.setItem(k, v));
+					return true;
+				})()
+			`, data), nil),
+		)
+		if err != nil {
+			log.Printf("Warning: Failed to restore localStorage for %s: %v\n", origin, err)
+		}
+	}
+
+	log.Printf("Session restored from %s (saved at %s)\n", path, session.SavedAt.Format(time.RFC3339))
+	return nil
+}
+
+// sessionPassphrase returns the ITANDI_SESSION_KEY passphrase
+func sessionPassphrase() (string, error) {
+	passphrase := os.Getenv(sessionKeyEnv)
+	if passphrase == "" {
+		return "", fmt.Errorf("%s environment variable must be set to encrypt session files", sessionKeyEnv)
+	}
+	return passphrase, nil
+}
+
+// sessionCipher derives the AES-256 key from passphrase and salt with scrypt
+func sessionCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, sessionScryptN, sessionScryptR, sessionScryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive session key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// encryptSession encrypts plaintext with AES-GCM under a key derived from
+// passphrase. The file is the magic, a random salt, the nonce and the
+// ciphertext; the magic and salt are authenticated with it.
+func encryptSession(passphrase string, plaintext []byte) ([]byte, error) {
+	header := make([]byte, len(sessionFileMagic)+sessionSaltSize)
+	copy(header, sessionFileMagic)
+	if _, err := io.ReadFull(rand.Reader, header[len(sessionFileMagic):]); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := sessionCipher(passphrase, header[len(sessionFileMagic):])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// decryptSession reverses encryptSession
+func decryptSession(passphrase string, data []byte) ([]byte, error) {
+	headerSize := len(sessionFileMagic) + sessionSaltSize
+	if len(data) < headerSize || string(data[:len(sessionFileMagic)]) != sessionFileMagic {
+		return nil, errors.New("session file is corrupted or from an older version - save the session again")
+	}
+	header := data[:headerSize]
+	gcm, err := sessionCipher(passphrase, header[len(sessionFileMagic):])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerSize+gcm.NonceSize() {
+		return nil, errors.New("session file is corrupted")
+	}
+	nonce, ciphertext := data[headerSize:headerSize+gcm.NonceSize()], data[headerSize+gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt session file (wrong %s?): %w", sessionKeyEnv, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncryptSessionRoundTrip(t *testing.T) {
+	plaintext := []byte(`{"cookies":[{"name":"session","value":"abc"}]}`)
+	data, err := encryptSession("passphrase", plaintext)
+	if err != nil {
+		t.Fatalf("encryptSession: %v", err)
+	}
+	if !strings.HasPrefix(string(data), sessionFileMagic) {
+		t.Errorf("session file does not start with the magic header")
+	}
+	if bytes.Contains(data, plaintext) {
+		t.Error("session file contains the plaintext")
+	}
+
+	got, err := decryptSession("passphrase", data)
+	if err != nil {
+		t.Fatalf("decryptSession: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted = %q, want %q", got, plaintext)
+	}
+
+	// Each file gets its own salt and nonce
+	again, err := encryptSession("passphrase", plaintext)
+	if err != nil {
+		t.Fatalf("encryptSession: %v", err)
+	}
+	headerSize := len(sessionFileMagic) + sessionSaltSize
+	if bytes.Equal(data[:headerSize], again[:headerSize]) {
+		t.Error("two session files share the same salt")
+	}
+}
+
+func TestDecryptSessionWrongPassphrase(t *testing.T) {
+	data, err := encryptSession("passphrase", []byte("cookies"))
+	if err != nil {
+		t.Fatalf("encryptSession: %v", err)
+	}
+	_, err = decryptSession("other passphrase", data)
+	if err == nil || !strings.Contains(err.Error(), sessionKeyEnv) {
+		t.Errorf("decryptSession with the wrong passphrase = %v, want an error naming %s", err, sessionKeyEnv)
+	}
+}
+
+func TestDecryptSessionTampered(t *testing.T) {
+	data, err := encryptSession("passphrase", []byte("cookies"))
+	if err != nil {
+		t.Fatalf("encryptSession: %v", err)
+	}
+	headerSize := len(sessionFileMagic) + sessionSaltSize
+
+	tests := map[string]func([]byte) []byte{
+		"ciphertext": func(b []byte) []byte { b[len(b)-1] ^= 0x01; return b },
+		"salt":       func(b []byte) []byte { b[len(sessionFileMagic)] ^= 0x01; return b },
+		"magic":      func(b []byte) []byte { b[0] ^= 0x01; return b },
+		"truncated":  func(b []byte) []byte { return b[:headerSize+4] },
+		"short":      func(b []byte) []byte { return b[:3] },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decryptSession("passphrase", tamper(bytes.Clone(data))); err == nil {
+				t.Error("tampered session file decrypted")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// SiteConfig はアクセス先（ITANDIアカウント・ITANDI BB）のURL設定
+type SiteConfig struct {
+	// AccountsURL is the ITANDI accounts site that hosts the login page
+	AccountsURL string `json:"accounts_url"`
+
+	// BBURL is the ITANDI BB site
+	BBURL string `json:"bb_url"`
+
+	Paths SitePaths `json:"paths"`
+
+	// LoginCandidates are probed by the login finder tools, relative to
+	// AccountsURL unless absolute
+	LoginCandidates []string `json:"login_candidates"`
+}
+
+// SitePaths are the known pages, relative to their site's base URL
+type SitePaths struct {
+	Login     string `json:"login"`      // on AccountsURL
+	Top       string `json:"top"`        // on BBURL
+	RentList  string `json:"rent_list"`  // on BBURL
+	RentRooms string `json:"rent_rooms"` // on BBURL, prefix of room pages
+}
+
+// DefaultSiteConfig returns the production ITANDI endpoints
+func DefaultSiteConfig() SiteConfig {
+	return SiteConfig{
+		AccountsURL: "https://itandi-accounts.com",
+		BBURL:       "https://itandibb.com",
+		Paths: SitePaths{
+			Login:     "/",
+			Top:       "/top",
+			RentList:  "/rent_rooms/list",
+			RentRooms: "/rent_rooms/",
+		},
+		LoginCandidates: []string{
+			"/",
+			"/login",
+			"/sign_in",
+			"https://bukkakun.com/login",
+			"https://bukkakun.com/sign_in",
+			"https://bukkakun.com/users/sign_in",
+			"https://bukkakun.com/auth/login",
+			"https://itandi.co.jp/login",
+			"https://accounts.itandi.co.jp/",
+			"https://accounts.itandi.com/",
+			"https://login.itandi.com/",
+			"https://auth.itandi.com/",
+			"https://itandi.com/login",
+			"https://app.itandi.com/login",
+		},
+	}
+}
+
+// LoadSiteConfig reads a JSON site config on top of the defaults
+func LoadSiteConfig(path string) (SiteConfig, error) {
+	cfg := DefaultSiteConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read site config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse site config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that both base URLs are absolute http(s) URLs
+func (c SiteConfig) Validate() error {
+	for name, base := range map[string]string{"accounts_url": c.AccountsURL, "bb_url": c.BBURL} {
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s %q: must be an absolute http(s) URL", name, base)
+		}
+	}
+	return nil
+}
+
+// LoginURL is the page where the login flow starts
+func (c SiteConfig) LoginURL() string {
+	return joinSiteURL(c.AccountsURL, c.Paths.Login)
+}
+
+// TopURL is the ITANDI BB top page shown after login
+func (c SiteConfig) TopURL() string {
+	return joinSiteURL(c.BBURL, c.Paths.Top)
+}
+
+// RentListURL is the rental list search page
+func (c SiteConfig) RentListURL() string {
+	return joinSiteURL(c.BBURL, c.Paths.RentList)
+}
+
+// LoginCandidateURLs resolves LoginCandidates against AccountsURL
+func (c SiteConfig) LoginCandidateURLs() []string {
+	urls := make([]string, 0, len(c.LoginCandidates))
+	for _, candidate := range c.LoginCandidates {
+		urls = append(urls, joinSiteURL(c.AccountsURL, candidate))
+	}
+	return urls
+}
+
+// IsLoginPage reports whether pageURL is the login page
+func (c SiteConfig) IsLoginPage(pageURL string) bool {
+	login := c.LoginURL()
+	return pageURL == login || pageURL == joinSiteURL(c.AccountsURL, "/login")
+}
+
+// IsTopPage reports whether pageURL is the ITANDI BB top page (or a subdomain of it)
+func (c SiteConfig) IsTopPage(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	bb, err := url.Parse(c.BBURL)
+	if err != nil {
+		return false
+	}
+	host, bbHost := strings.ToLower(u.Host), strings.ToLower(bb.Host)
+	if host != bbHost && !strings.HasSuffix(host, "."+bbHost) {
+		return false
+	}
+	// Match whole path segments so "/top" does not accept "/topics"
+	top := strings.TrimSuffix(c.Paths.Top, "/")
+	return u.Path == top || strings.HasPrefix(u.Path, top+"/")
+}
+
+// joinSiteURL joins a base URL and a path; absolute paths are returned as is
+func joinSiteURL(base, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestSiteConfigURLs(t *testing.T) {
+	site := DefaultSiteConfig()
+	site.AccountsURL = "http://127.0.0.1:8080/"
+	site.BBURL = "http://127.0.0.1:9090"
+
+	if got, want := site.LoginURL(), "http://127.0.0.1:8080/"; got != want {
+		t.Errorf("LoginURL() = %q, want %q", got, want)
+	}
+	if got, want := site.TopURL(), "http://127.0.0.1:9090/top"; got != want {
+		t.Errorf("TopURL() = %q, want %q", got, want)
+	}
+	if got, want := site.RentListURL(), "http://127.0.0.1:9090/rent_rooms/list"; got != want {
+		t.Errorf("RentListURL() = %q, want %q", got, want)
+	}
+
+	candidates := site.LoginCandidateURLs()
+	if candidates[1] != "http://127.0.0.1:8080/login" {
+		t.Errorf("relative candidate = %q", candidates[1])
+	}
+	if candidates[3] != "https://bukkakun.com/login" {
+		t.Errorf("absolute candidate = %q", candidates[3])
+	}
+}
+
+func TestSiteConfigIsTopPage(t *testing.T) {
+	site := DefaultSiteConfig()
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://itandibb.com/top", true},
+		{"https://www.itandibb.com/top?tab=rent", true},
+		{"https://itandibb.com/rent_rooms/list", false},
+		{"https://itandi-accounts.com/top", false},
+		{"https://itandibb.com/top/", true},
+		{"https://itandibb.com/top/notices", true},
+		{"https://ITANDIBB.com/top", true},
+		{"https://itandibb.com/topics", false},
+		{"https://itandibb.com/", false},
+		{"https://evilitandibb.com/top", false},
+		{"https://itandibb.com.evil.example/top", false},
+		{"::", false},
+	}
+	for _, tt := range tests {
+		if got := site.IsTopPage(tt.url); got != tt.want {
+			t.Errorf("IsTopPage(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSiteConfigValidate(t *testing.T) {
+	site := DefaultSiteConfig()
+	if err := site.Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+	site.BBURL = "itandibb.com"
+	if err := site.Validate(); err == nil {
+		t.Error("expected error for URL without scheme")
+	}
+}
@@ -7,11 +7,11 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-func testModalHandling() {
+func testModalHandling(cfg BrowserConfig, site SiteConfig) {
 	log.Println("=== Testing Modal Advertisement Handling ===")
 	
-	// Create scraper instance with visible browser
-	scraper, err := NewITANDIScraper(false)
+	// Create scraper instance (visible unless -headless)
+	scraper, err := NewITANDIScraperWithConfig(cfg, site, SessionOptions{})
 	if err != nil {
 		log.Fatal("Failed to create scraper:", err)
 	}
@@ -33,7 +33,7 @@ func testModalHandling() {
 	// Navigate to search page directly to test modal handling
 	log.Println("Navigating to search page...")
 	err = chromedp.Run(scraper.ctx,
-		chromedp.Navigate("https://itandibb.com/rent_rooms/list"),
+		chromedp.Navigate(site.RentListURL()),
 		chromedp.WaitReady("body"),
 	)
 	if err != nil {
@@ -52,6 +52,8 @@ func testModalHandling() {
 	// Take screenshot
 	scraper.TakeScreenshot("test_modal_after_close.png")
 	
-	log.Println("Keeping browser open for inspection...")
-	time.Sleep(30 * time.Second)
+	if !cfg.Headless {
+		log.Println("Keeping browser open for inspection...")
+		time.Sleep(30 * time.Second)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsers for the Japanese value notations shown on ITANDI BB
+// (賃料 "8.5万円", 敷金 "1ヶ月", 面積 "25.3m²", 間取り "2LDK", 入居 "即入居可" ...)
+
+// Fee は敷金・礼金などの金額（円）または賃料に対する月数
+type Fee struct {
+	Yen    int     `json:"yen"`
+	Months float64 `json:"months,omitempty"`
+}
+
+// Layout は間取りの構造化表現
+type Layout struct {
+	Rooms   int  `json:"rooms"`
+	OneRoom bool `json:"one_room,omitempty"`
+	Living  bool `json:"living,omitempty"`
+	Dining  bool `json:"dining,omitempty"`
+	Kitchen bool `json:"kitchen,omitempty"`
+	Storage bool `json:"storage,omitempty"`
+}
+
+// String formats the layout the way ITANDI BB displays it (e.g. "2SLDK", "1R")
+func (l Layout) String() string {
+	if l.OneRoom {
+		return "1R"
+	}
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(l.Rooms))
+	if l.Storage {
+		b.WriteString("S")
+	}
+	if l.Living {
+		b.WriteString("L")
+	}
+	if l.Dining {
+		b.WriteString("D")
+	}
+	if l.Kitchen {
+		b.WriteString("K")
+	}
+	return b.String()
+}
+
+// AvailabilityPeriod は入居可能時期の粒度
+type AvailabilityPeriod string
+
+const (
+	PeriodDay    AvailabilityPeriod = "day"
+	PeriodEarly  AvailabilityPeriod = "early"  // 上旬
+	PeriodMiddle AvailabilityPeriod = "middle" // 中旬
+	PeriodLate   AvailabilityPeriod = "late"   // 下旬
+	PeriodMonth  AvailabilityPeriod = "month"
+)
+
+// Availability は入居可能時期
+type Availability struct {
+	Immediate  bool               `json:"immediate,omitempty"`
+	Negotiable bool               `json:"negotiable,omitempty"`
+	From       time.Time          `json:"from,omitzero"`
+	Period     AvailabilityPeriod `json:"period,omitempty"`
+}
+
+var (
+	manYenValuePattern = regexp.MustCompile(`^([\d,]+(?:\.\d+)?)万(?:([\d,]+)円?|円)?$`)
+	yenValuePattern    = regexp.MustCompile(`^([\d,]+)円$`)
+	monthsValuePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:ヶ月|ヵ月|カ月|か月|ケ月|箇月|月)(?:分)?$`)
+	areaValuePattern   = regexp.MustCompile(`([\d,]+(?:\.\d+)?)(?:㎡|m²|m2|平米|平方メートル)`)
+	floorValuePattern  = regexp.MustCompile(`(?:地下|B)?(\d+)階`)
+	layoutValuePattern = regexp.MustCompile(`^(\d+)(S?)(L?)(D?)(K?)(?:\+S|\+納戸)?$`)
+	dateDayPattern     = regexp.MustCompile(`^(?:(\d{4})[年/.-])?(\d{1,2})[月/.-](\d{1,2})日?`)
+	dateMonthPattern   = regexp.MustCompile(`^(?:(\d{4})[年/.-])?(\d{1,2})月?(上旬|中旬|下旬|初旬|末)?`)
+)
+
+// noneValues are the notations ITANDI BB uses for "no charge"
+var noneValues = map[string]bool{
+	"なし": true, "無し": true, "無": true, "ナシ": true, "-": true, "ー": true, "―": true, "0": true, "0円": true, "0ヶ月": true,
+}
+
+// normalizeValue converts full-width characters and strips decorations before parsing
+func normalizeValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '０' && r <= '９':
+			b.WriteRune(r - '０' + '0')
+		case r >= 'Ａ' && r <= 'Ｚ':
+			b.WriteRune(r - 'Ａ' + 'A')
+		case r >= 'ａ' && r <= 'ｚ':
+			b.WriteRune(r - 'ａ' + 'a')
+		case r == '．':
+			b.WriteRune('.')
+		case r == '，':
+			b.WriteRune(',')
+		case r == '／':
+			b.WriteRune('/')
+		case r == '－', r == '−':
+			b.WriteRune('-')
+		case r == ' ', r == '　', r == '\t', r == '\n', r == '\r', r == '¥', r == '￥':
+			// drop whitespace and currency marks
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isNoneValue reports whether s means "no charge"
+func isNoneValue(s string) bool {
+	return noneValues[s]
+}
+
+// ParseYen converts values such as "8.5万円", "8万5000円", "85,000円" or "なし" to yen
+func ParseYen(s string) (int, error) {
+	v := normalizeValue(s)
+	if isNoneValue(v) {
+		return 0, nil
+	}
+	if m := manYenValuePattern.FindStringSubmatch(v); m != nil {
+		man, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid yen value %q: %w", s, err)
+		}
+		yen := int(math.Round(man * 10000))
+		if m[2] != "" {
+			rest, err := strconv.Atoi(strings.ReplaceAll(m[2], ",", ""))
+			if err != nil {
+				return 0, fmt.Errorf("invalid yen value %q: %w", s, err)
+			}
+			yen += rest
+		}
+		return yen, nil
+	}
+	if m := yenValuePattern.FindStringSubmatch(v); m != nil {
+		yen, err := strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+		if err != nil {
+			return 0, fmt.Errorf("invalid yen value %q: %w", s, err)
+		}
+		return yen, nil
+	}
+	return 0, fmt.Errorf("unrecognized yen value %q", s)
+}
+
+// ParseFee converts deposit/key money notations ("1ヶ月", "5万円", "なし") to yen.
+// Month multiples are resolved against rent.
+func ParseFee(s string, rent int) (Fee, error) {
+	v := normalizeValue(s)
+	if isNoneValue(v) {
+		return Fee{}, nil
+	}
+	if m := monthsValuePattern.FindStringSubmatch(v); m != nil {
+		months, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return Fee{}, fmt.Errorf("invalid fee value %q: %w", s, err)
+		}
+		return Fee{Yen: int(math.Round(months * float64(rent))), Months: months}, nil
+	}
+	yen, err := ParseYen(s)
+	if err != nil {
+		return Fee{}, err
+	}
+	fee := Fee{Yen: yen}
+	if rent > 0 && yen > 0 {
+		fee.Months = math.Round(float64(yen)/float64(rent)*100) / 100
+	}
+	return fee, nil
+}
+
+// ParseArea converts values such as "25.3m²" or "44.61㎡" to square meters
+func ParseArea(s string) (float64, error) {
+	m := areaValuePattern.FindStringSubmatch(normalizeValue(s))
+	if m == nil {
+		return 0, fmt.Errorf("unrecognized area value %q", s)
+	}
+	area, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid area value %q: %w", s, err)
+	}
+	return area, nil
+}
+
+// ParseFloor converts values such as "3階" or "地下1階" to a floor number (basements are negative)
+func ParseFloor(s string) (int, error) {
+	v := normalizeValue(s)
+	m := floorValuePattern.FindStringSubmatch(v)
+	if m == nil {
+		return 0, fmt.Errorf("unrecognized floor value %q", s)
+	}
+	floor, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid floor value %q: %w", s, err)
+	}
+	if strings.HasPrefix(m[0], "地下") || strings.HasPrefix(m[0], "B") {
+		floor = -floor
+	}
+	return floor, nil
+}
+
+// ParseLayout converts values such as "2LDK", "1K", "3SLDK" or "ワンルーム" to a Layout
+func ParseLayout(s string) (Layout, error) {
+	v := strings.ToUpper(normalizeValue(s))
+	if v == "ワンルーム" || v == "1R" || v == "R" {
+		return Layout{Rooms: 1, OneRoom: true}, nil
+	}
+	m := layoutValuePattern.FindStringSubmatch(v)
+	if m == nil {
+		return Layout{}, fmt.Errorf("unrecognized layout value %q", s)
+	}
+	rooms, err := strconv.Atoi(m[1])
+	if err != nil {
+		return Layout{}, fmt.Errorf("invalid layout value %q: %w", s, err)
+	}
+	return Layout{
+		Rooms:   rooms,
+		Storage: m[2] != "" || strings.Contains(v, "+"),
+		Living:  m[3] != "",
+		Dining:  m[4] != "",
+		Kitchen: m[5] != "",
+	}, nil
+}
+
+// ParseAvailability converts 入居可能時期 notations ("即入居可", "2025年4月上旬", "4/15", "相談") to an Availability.
+// Dates without a year are resolved to the next occurrence relative to now.
+func ParseAvailability(s string, now time.Time) (Availability, error) {
+	v := normalizeValue(s)
+	v = strings.TrimPrefix(v, "入居")
+	v = strings.TrimPrefix(v, "可能")
+	v = strings.TrimSuffix(v, "予定")
+	switch {
+	case v == "":
+		return Availability{}, fmt.Errorf("empty availability value")
+	case strings.HasPrefix(v, "即"):
+		return Availability{Immediate: true, From: truncateDay(now), Period: PeriodDay}, nil
+	case strings.Contains(v, "相談"):
+		return Availability{Negotiable: true}, nil
+	}
+
+	if m := dateDayPattern.FindStringSubmatch(v); m != nil {
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		year := resolveYear(m[1], month, now)
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			return Availability{}, fmt.Errorf("invalid availability date %q", s)
+		}
+		return Availability{From: time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location()), Period: PeriodDay}, nil
+	}
+	if m := dateMonthPattern.FindStringSubmatch(v); m != nil && (m[1] != "" || strings.Contains(v, "月")) {
+		month, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 {
+			return Availability{}, fmt.Errorf("invalid availability month %q", s)
+		}
+		year := resolveYear(m[1], month, now)
+		day, period := 1, PeriodMonth
+		switch m[3] {
+		case "上旬", "初旬":
+			day, period = 1, PeriodEarly
+		case "中旬":
+			day, period = 11, PeriodMiddle
+		case "下旬", "末":
+			day, period = 21, PeriodLate
+		}
+		return Availability{From: time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location()), Period: period}, nil
+	}
+	return Availability{}, fmt.Errorf("unrecognized availability value %q", s)
+}
+
+// resolveYear returns the explicit year or the next year in which month occurs
+func resolveYear(explicit string, month int, now time.Time) int {
+	if explicit != "" {
+		year, _ := strconv.Atoi(explicit)
+		return year
+	}
+	if month < int(now.Month()) {
+		return now.Year() + 1
+	}
+	return now.Year()
+}
+
+// truncateDay drops the time of day
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseYen(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"8.5万円", 85000},
+		{"7.7万円", 77000},
+		{"12万円", 120000},
+		{"8万5000円", 85000},
+		{"85,000円", 85000},
+		{"5000円", 5000},
+		{"¥5,000円", 5000},
+		{"８．５万円", 85000},
+		{" 6.25 万円 ", 62500},
+		{"なし", 0},
+		{"-", 0},
+		{"0円", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseYen(tt.in)
+		if err != nil {
+			t.Errorf("ParseYen(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseYen(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "相談", "1ヶ月", "万円"} {
+		if _, err := ParseYen(in); err == nil {
+			t.Errorf("ParseYen(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseFee(t *testing.T) {
+	tests := []struct {
+		in   string
+		rent int
+		want Fee
+	}{
+		{"1ヶ月", 85000, Fee{Yen: 85000, Months: 1}},
+		{"2ヵ月", 77000, Fee{Yen: 154000, Months: 2}},
+		{"1.5か月", 80000, Fee{Yen: 120000, Months: 1.5}},
+		{"１ヶ月", 60000, Fee{Yen: 60000, Months: 1}},
+		{"7.7万円", 77000, Fee{Yen: 77000, Months: 1}},
+		{"5万円", 100000, Fee{Yen: 50000, Months: 0.5}},
+		{"なし", 85000, Fee{}},
+		{"0ヶ月", 85000, Fee{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFee(tt.in, tt.rent)
+		if err != nil {
+			t.Errorf("ParseFee(%q, %d) returned error: %v", tt.in, tt.rent, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFee(%q, %d) = %+v, want %+v", tt.in, tt.rent, got, tt.want)
+		}
+	}
+
+	if _, err := ParseFee("要相談", 85000); err == nil {
+		t.Error("ParseFee(\"要相談\") expected error")
+	}
+}
+
+func TestParseArea(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"25.3m²", 25.3},
+		{"44.61㎡", 44.61},
+		{"30m2", 30},
+		{"２５．５㎡", 25.5},
+		{"専有面積 52.10 ㎡", 52.1},
+		{"20平米", 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseArea(tt.in)
+		if err != nil {
+			t.Errorf("ParseArea(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseArea(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseArea("2LDK"); err == nil {
+		t.Error("ParseArea(\"2LDK\") expected error")
+	}
+}
+
+func TestParseFloor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"3階", 3},
+		{"12階", 12},
+		{"３階", 3},
+		{"地下1階", -1},
+		{"B2階", -2},
+		{"3階/10階建", 3},
+	}
+	for _, tt := range tests {
+		got, err := ParseFloor(tt.in)
+		if err != nil {
+			t.Errorf("ParseFloor(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFloor(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Layout
+	}{
+		{"2LDK", Layout{Rooms: 2, Living: true, Dining: true, Kitchen: true}},
+		{"1K", Layout{Rooms: 1, Kitchen: true}},
+		{"1DK", Layout{Rooms: 1, Dining: true, Kitchen: true}},
+		{"3SLDK", Layout{Rooms: 3, Storage: true, Living: true, Dining: true, Kitchen: true}},
+		{"2LDK+S", Layout{Rooms: 2, Storage: true, Living: true, Dining: true, Kitchen: true}},
+		{"ワンルーム", Layout{Rooms: 1, OneRoom: true}},
+		{"1R", Layout{Rooms: 1, OneRoom: true}},
+		{"２ＬＤＫ", Layout{Rooms: 2, Living: true, Dining: true, Kitchen: true}},
+		{"1ldk", Layout{Rooms: 1, Living: true, Dining: true, Kitchen: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLayout(tt.in)
+		if err != nil {
+			t.Errorf("ParseLayout(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLayout(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "LDK", "44.61㎡"} {
+		if _, err := ParseLayout(in); err == nil {
+			t.Errorf("ParseLayout(%q) expected error", in)
+		}
+	}
+}
+
+func TestLayoutString(t *testing.T) {
+	for _, in := range []string{"2LDK", "1K", "3SLDK", "1R"} {
+		layout, err := ParseLayout(in)
+		if err != nil {
+			t.Fatalf("ParseLayout(%q) returned error: %v", in, err)
+		}
+		if got := layout.String(); got != in {
+			t.Errorf("Layout(%q).String() = %q", in, got)
+		}
+	}
+}
+
+func TestParseAvailability(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	now := time.Date(2025, 6, 15, 10, 30, 0, 0, jst)
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, jst)
+	}
+
+	tests := []struct {
+		in   string
+		want Availability
+	}{
+		{"即入居可", Availability{Immediate: true, From: date(2025, 6, 15), Period: PeriodDay}},
+		{"即日", Availability{Immediate: true, From: date(2025, 6, 15), Period: PeriodDay}},
+		{"入居即可", Availability{Immediate: true, From: date(2025, 6, 15), Period: PeriodDay}},
+		{"相談", Availability{Negotiable: true}},
+		{"2025年7月上旬", Availability{From: date(2025, 7, 1), Period: PeriodEarly}},
+		{"2025年7月中旬", Availability{From: date(2025, 7, 11), Period: PeriodMiddle}},
+		{"2025年7月下旬", Availability{From: date(2025, 7, 21), Period: PeriodLate}},
+		{"2025年8月", Availability{From: date(2025, 8, 1), Period: PeriodMonth}},
+		{"2025/08/20", Availability{From: date(2025, 8, 20), Period: PeriodDay}},
+		{"2025年9月5日", Availability{From: date(2025, 9, 5), Period: PeriodDay}},
+		{"8月中旬予定", Availability{From: date(2025, 8, 11), Period: PeriodMiddle}},
+		{"3月下旬", Availability{From: date(2026, 3, 21), Period: PeriodLate}},
+		{"4/1", Availability{From: date(2026, 4, 1), Period: PeriodDay}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAvailability(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseAvailability(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got.Immediate != tt.want.Immediate || got.Negotiable != tt.want.Negotiable ||
+			!got.From.Equal(tt.want.From) || got.Period != tt.want.Period {
+			t.Errorf("ParseAvailability(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "未定", "13月"} {
+		if _, err := ParseAvailability(in, now); err == nil {
+			t.Errorf("ParseAvailability(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// waitPollInterval is how often conditions are re-evaluated
+	waitPollInterval = 100 * time.Millisecond
+
+	// networkQuietPeriod is how long the network must stay quiet to count as idle
+	networkQuietPeriod = 500 * time.Millisecond
+
+	// networkIdleMaxInflight tolerates long-lived requests (analytics, polling)
+	// the same way Puppeteer's networkidle2 does
+	networkIdleMaxInflight = 2
+)
+
+// WaitTimeouts are the per-step limits for condition-based waits
+type WaitTimeouts struct {
+	Page    time.Duration `json:"page"`    // page load and navigation
+	Login   time.Duration `json:"login"`   // login form and redirect after submit
+	Search  time.Duration `json:"search"`  // search page controls
+	Results time.Duration `json:"results"` // search results after submit
+	Modal   time.Duration `json:"modal"`   // modal advertisements closing
+}
+
+// DefaultWaitTimeouts returns limits that tolerate a slow ITANDI BB response
+func DefaultWaitTimeouts() WaitTimeouts {
+	return WaitTimeouts{
+		Page:    15 * time.Second,
+		Login:   20 * time.Second,
+		Search:  15 * time.Second,
+		Results: 30 * time.Second,
+		Modal:   3 * time.Second,
+	}
+}
+
+// WaitTimeoutError reports which condition was not met in time. It matches ErrTimeout.
+type WaitTimeoutError struct {
+	Step      string
+	Condition string
+	Timeout   time.Duration
+
+	// LastErr is the last error seen while evaluating the condition, if any
+	LastErr error
+}
+
+func (e *WaitTimeoutError) Error() string {
+	msg := fmt.Sprintf("%s: timed out after %s waiting for %s", e.Step, e.Timeout, e.Condition)
+	if e.LastErr != nil {
+		msg += fmt.Sprintf(" (last error: %v)", e.LastErr)
+	}
+	return msg
+}
+
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.LastErr
+}
+
+// Is makes wait timeouts match ErrTimeout
+func (e *WaitTimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// waitCondition is a named check polled until it returns true
+type waitCondition struct {
+	name  string
+	check func(ctx context.Context) (bool, error)
+}
+
+// waitFor polls the conditions in order until each is met, sharing one
+// timeout for the whole step
+func waitFor(ctx context.Context, step string, timeout time.Duration, conds ...waitCondition) error {
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, cond := range conds {
+		var lastErr error
+		for {
+			ok, err := cond.check(waitCtx)
+			if err == nil && ok {
+				break
+			}
+			if err != nil && waitCtx.Err() == nil {
+				// Pages being replaced by a navigation fail to evaluate; keep polling
+				lastErr = err
+			}
+
+			select {
+			case <-waitCtx.Done():
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return &WaitTimeoutError{Step: step, Condition: cond.name, Timeout: timeout, LastErr: lastErr}
+			case <-time.After(waitPollInterval):
+			}
+		}
+	}
+
+	log.Printf("%s: ready after %s\n", step, time.Since(start).Round(10*time.Millisecond))
+	return nil
+}
+
+// waitJS waits for a JavaScript expression to evaluate to true
+func waitJS(name, expr string) waitCondition {
+	return waitCondition{
+		name: name,
+		check: func(ctx context.Context) (bool, error) {
+			var ok bool
+			err := chromedp.Run(ctx, chromedp.Evaluate(expr, &ok))
+			return ok, err
+		},
+	}
+}
+
+// waitAny waits for any one of conds to be met
+func waitAny(name string, conds ...waitCondition) waitCondition {
+	return waitCondition{
+		name: name,
+		check: func(ctx context.Context) (bool, error) {
+			var lastErr error
+			for _, cond := range conds {
+				ok, err := cond.check(ctx)
+				if err == nil && ok {
+					return true, nil
+				}
+				if err != nil {
+					lastErr = err
+				}
+			}
+			return false, lastErr
+		},
+	}
+}
+
+// waitDOMReady waits for the document to finish loading
+func waitDOMReady() waitCondition {
+	return waitJS("document ready", `document.readyState === 'complete'`)
+}
+
+// waitLocator waits for any of locators to match an element
+func waitLocator(field string, locators []string) waitCondition {
+	return waitCondition{
+		name: fmt.Sprintf("%s to appear", field),
+		check: func(ctx context.Context) (bool, error) {
+			for _, locator := range locators {
+				kind, query := locatorArgs(locator)
+				var found bool
+				err := chromedp.Run(ctx,
+					chromedp.Evaluate(locatorScript(fmt.Sprintf(`
+						return __crmLocate(%s, %s).length > 0;
+					`, kind, query)), &found),
+				)
+				if err != nil {
+					return false, err
+				}
+				if found {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+	}
+}
+
+// waitURL waits for the page URL to satisfy match
+func waitURL(name string, match func(string) bool) waitCondition {
+	return waitCondition{
+		name: name,
+		check: func(ctx context.Context) (bool, error) {
+			var url string
+			if err := chromedp.Run(ctx, chromedp.Location(&url)); err != nil {
+				return false, err
+			}
+			return match(url), nil
+		},
+	}
+}
+
+// waitURLChange waits for the page to navigate away from from
+func waitURLChange(from string) waitCondition {
+	return waitURL(fmt.Sprintf("URL to change from %s", from), func(url string) bool {
+		return url != from
+	})
+}
+
+// waitNetworkIdle waits until the tracker has seen no more than
+// networkIdleMaxInflight open requests for networkQuietPeriod. The quiet period
+// starts no earlier than the call, so requests triggered by a preceding click
+// have time to begin.
+func waitNetworkIdle(tracker *networkTracker) waitCondition {
+	since := time.Now()
+	return waitCondition{
+		name: "network idle",
+		check: func(ctx context.Context) (bool, error) {
+			return tracker.idle(since, networkQuietPeriod), nil
+		},
+	}
+}
+
+// waitResults waits for the labelled result count, a listing link or a
+// no-results message. An unrelated "N件" elsewhere on the page does not count.
+func waitResults() waitCondition {
+	return waitJS("result count or no-results message", `
+		(() => {
+			const text = document.body ? document.body.innerText : '';
+			if (/検索結果がありません|該当する物件がありません|見つかりませんでした/.test(text)) return true;
+			if (/`+resultCountPattern+`/.test(text)) return true;
+			return document.querySelector('a[href*="/rent_rooms/"]') !== null;
+		})()
+	`)
+}
+
+// modalVisibleJS reports whether the イタンジ売却査定 advertisement is visible
+const modalVisibleJS = `
+	(() => {
+		for (const el of document.querySelectorAll('body *')) {
+			if (el.children.length > 0 || !el.textContent.includes('イタンジ売却査定')) continue;
+			const style = window.getComputedStyle(el);
+			if (style.display !== 'none' && style.visibility !== 'hidden' && el.getClientRects().length > 0) {
+				return true;
+			}
+		}
+		return false;
+	})()
+`
+
+// waitModalShown waits for the イタンジ売却査定 advertisement or a modal close
+// button to appear
+func waitModalShown(closeLocators []string) waitCondition {
+	return waitAny("modal advertisement or close button",
+		waitJS("modal advertisement", modalVisibleJS),
+		waitLocator("modal.close", closeLocators))
+}
+
+// waitModalGone waits for the イタンジ売却査定 advertisement to disappear
+func waitModalGone() waitCondition {
+	return waitJS("modal advertisement to close", `!`+strings.TrimSpace(modalVisibleJS))
+}
+
+// networkTracker counts in-flight requests of a browser tab
+type networkTracker struct {
+	mu           sync.Mutex
+	inflight     map[network.RequestID]struct{}
+	lastActivity time.Time
+}
+
+// trackNetwork starts counting the requests made by the tab in ctx
+func trackNetwork(ctx context.Context) *networkTracker {
+	t := &networkTracker{
+		inflight:     make(map[network.RequestID]struct{}),
+		lastActivity: time.Now(),
+	}
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			// Streaming connections never finish and would keep the page busy forever
+			if ev.Type == network.ResourceTypeWebSocket || ev.Type == network.ResourceTypeEventSource {
+				return
+			}
+			t.inflight[ev.RequestID] = struct{}{}
+		case *network.EventLoadingFinished:
+			delete(t.inflight, ev.RequestID)
+		case *network.EventLoadingFailed:
+			delete(t.inflight, ev.RequestID)
+		default:
+			return
+		}
+		t.lastActivity = time.Now()
+	})
+
+	return t
+}
+
+// idle reports whether the network has been quiet for at least quiet, counting from since at the earliest
+func (t *networkTracker) idle(since time.Time, quiet time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lastActivity.After(since) {
+		since = t.lastActivity
+	}
+	return len(t.inflight) <= networkIdleMaxInflight && time.Since(since) >= quiet
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestWaitForReportsTimedOutCondition(t *testing.T) {
+	met := waitCondition{name: "always met", check: func(context.Context) (bool, error) { return true, nil }}
+	evalErr := errors.New("execution context was destroyed")
+	never := waitCondition{name: "never met", check: func(context.Context) (bool, error) { return false, evalErr }}
+
+	err := waitFor(context.Background(), "test step", 300*time.Millisecond, met, never)
+
+	var timeoutErr *WaitTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("err = %v, want WaitTimeoutError", err)
+	}
+	if timeoutErr.Step != "test step" || timeoutErr.Condition != "never met" {
+		t.Errorf("got step %q condition %q", timeoutErr.Step, timeoutErr.Condition)
+	}
+	if !errors.Is(err, evalErr) {
+		t.Errorf("err does not wrap the last evaluation error: %v", err)
+	}
+}
+
+func TestWaitForPollsUntilMet(t *testing.T) {
+	calls := 0
+	eventually := waitCondition{name: "third call", check: func(context.Context) (bool, error) {
+		calls++
+		return calls >= 3, nil
+	}}
+
+	if err := waitFor(context.Background(), "test step", time.Second, eventually); err != nil {
+		t.Fatalf("waitFor: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWaitForParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	never := waitCondition{name: "never met", check: func(context.Context) (bool, error) { return false, nil }}
+
+	err := waitFor(ctx, "test step", time.Second, never)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitAny(t *testing.T) {
+	evalErr := errors.New("execution context was destroyed")
+	failing := waitCondition{name: "failing", check: func(context.Context) (bool, error) { return false, evalErr }}
+	unmet := waitCondition{name: "unmet", check: func(context.Context) (bool, error) { return false, nil }}
+	met := waitCondition{name: "met", check: func(context.Context) (bool, error) { return true, nil }}
+
+	if ok, err := waitAny("any", failing, unmet, met).check(context.Background()); !ok || err != nil {
+		t.Errorf("one met: got %v, %v", ok, err)
+	}
+	if ok, err := waitAny("any", unmet, failing).check(context.Background()); ok || !errors.Is(err, evalErr) {
+		t.Errorf("none met: got %v, %v, want false and the evaluation error", ok, err)
+	}
+}
+
+func TestNetworkTrackerIdle(t *testing.T) {
+	now := time.Now()
+	tracker := &networkTracker{
+		inflight:     map[network.RequestID]struct{}{"1": {}},
+		lastActivity: now.Add(-time.Second),
+	}
+
+	if !tracker.idle(now.Add(-time.Second), networkQuietPeriod) {
+		t.Error("one long-lived request should still count as idle")
+	}
+	if tracker.idle(now, networkQuietPeriod) {
+		t.Error("quiet period must start no earlier than since")
+	}
+
+	tracker.inflight["2"] = struct{}{}
+	tracker.inflight["3"] = struct{}{}
+	if tracker.idle(now.Add(-time.Second), networkQuietPeriod) {
+		t.Error("three open requests should not count as idle")
+	}
+}